@@ -0,0 +1,228 @@
+package parsers
+
+import (
+	"strings"
+)
+
+// beamTerm is a value read from Elixir or Erlang term syntax, as found in
+// mix.lock and rebar.lock. Maps and keyword lists are read as lists of
+// two-element tuples.
+type beamTerm struct {
+	kind  byte // 't' tuple, 'l' list, 's' string or binary, 'a' atom, 'n' number
+	text  string
+	items []beamTerm
+}
+
+// str returns the text of a string, binary or atom term.
+func (t beamTerm) str() string {
+	if t.kind == 's' || t.kind == 'a' {
+		return t.text
+	}
+	return ""
+}
+
+// item returns the i-th element of a tuple or list, or an empty term.
+func (t beamTerm) item(i int) beamTerm {
+	if i < len(t.items) {
+		return t.items[i]
+	}
+	return beamTerm{}
+}
+
+// keyword looks up key in a keyword list or map read as key/value tuples.
+func (t beamTerm) keyword(key string) (beamTerm, bool) {
+	for _, pair := range t.items {
+		if pair.kind == 't' && len(pair.items) == 2 && pair.items[0].str() == key {
+			return pair.items[1], true
+		}
+	}
+	return beamTerm{}, false
+}
+
+// parseBeamTerms reads a sequence of terms. Erlang files separate top-level
+// terms with "."; an Elixir file is a single map. Unreadable input stops the
+// scan and returns what was read so far.
+func parseBeamTerms(s string) []beamTerm {
+	p := &beamParser{src: s}
+	var terms []beamTerm
+	for {
+		p.skipSpace()
+		if p.pos >= len(p.src) {
+			return terms
+		}
+		t, ok := p.term()
+		if !ok {
+			return terms
+		}
+		terms = append(terms, t)
+		p.skipSpace()
+		if p.peek() == '.' {
+			p.pos++
+		}
+	}
+}
+
+type beamParser struct {
+	src string
+	pos int
+}
+
+func (p *beamParser) peek() byte {
+	if p.pos < len(p.src) {
+		return p.src[p.pos]
+	}
+	return 0
+}
+
+// skipSpace skips whitespace and "%" line comments. Elixir map sigils are
+// handled in term, so a lone "%" always starts a comment here.
+func (p *beamParser) skipSpace() {
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			p.pos++
+		case c == '%' && p.pos+1 < len(p.src) && p.src[p.pos+1] != '{':
+			for p.pos < len(p.src) && p.src[p.pos] != '\n' {
+				p.pos++
+			}
+		default:
+			return
+		}
+	}
+}
+
+func (p *beamParser) term() (beamTerm, bool) {
+	p.skipSpace()
+	switch c := p.peek(); {
+	case c == '{':
+		p.pos++
+		items, ok := p.sequence('}')
+		return beamTerm{kind: 't', items: items}, ok
+	case c == '[':
+		p.pos++
+		items, ok := p.sequence(']')
+		return beamTerm{kind: 'l', items: items}, ok
+	case c == '%' && strings.HasPrefix(p.src[p.pos:], "%{"):
+		p.pos += 2
+		items, ok := p.sequence('}')
+		return beamTerm{kind: 'l', items: items}, ok
+	case c == '<' && strings.HasPrefix(p.src[p.pos:], "<<"):
+		p.pos += 2
+		p.skipSpace()
+		text := ""
+		if p.peek() == '"' {
+			var ok bool
+			if text, ok = p.quoted('"'); !ok {
+				return beamTerm{}, false
+			}
+		}
+		p.skipSpace()
+		if !strings.HasPrefix(p.src[p.pos:], ">>") {
+			return beamTerm{}, false
+		}
+		p.pos += 2
+		return beamTerm{kind: 's', text: text}, true
+	case c == '"':
+		text, ok := p.quoted('"')
+		return beamTerm{kind: 's', text: text}, ok
+	case c == '\'':
+		text, ok := p.quoted('\'')
+		return beamTerm{kind: 'a', text: text}, ok
+	case c == ':':
+		p.pos++
+		if p.peek() == '"' {
+			text, ok := p.quoted('"')
+			return beamTerm{kind: 'a', text: text}, ok
+		}
+		return beamTerm{kind: 'a', text: p.word()}, true
+	case c == '-' || c >= '0' && c <= '9':
+		start := p.pos
+		p.pos++
+		p.word()
+		return beamTerm{kind: 'n', text: p.src[start:p.pos]}, true
+	case isBeamWordChar(c):
+		return beamTerm{kind: 'a', text: p.word()}, true
+	}
+	return beamTerm{}, false
+}
+
+// sequence reads comma-separated elements up to the closing delimiter.
+// Elixir keyword pairs ("key: value") and map entries ("k": v, k => v) are
+// returned as two-element tuples.
+func (p *beamParser) sequence(end byte) ([]beamTerm, bool) {
+	var items []beamTerm
+	for {
+		p.skipSpace()
+		if p.peek() == end {
+			p.pos++
+			return items, true
+		}
+		if p.peek() == ',' {
+			p.pos++
+			continue
+		}
+
+		item, ok := p.term()
+		if !ok {
+			return items, false
+		}
+		// "key": value in an Elixir map, or key: value in a keyword list
+		if item.kind == 's' || item.kind == 'a' {
+			if p.peek() == ':' {
+				p.pos++
+				key := beamTerm{kind: 'a', text: item.text}
+				value, ok := p.term()
+				if !ok {
+					return items, false
+				}
+				item = beamTerm{kind: 't', items: []beamTerm{key, value}}
+			}
+		}
+		p.skipSpace()
+		if strings.HasPrefix(p.src[p.pos:], "=>") {
+			p.pos += 2
+			value, ok := p.term()
+			if !ok {
+				return items, false
+			}
+			item = beamTerm{kind: 't', items: []beamTerm{item, value}}
+		}
+		items = append(items, item)
+	}
+}
+
+// quoted reads a string delimited by q, handling backslash escapes.
+func (p *beamParser) quoted(q byte) (string, bool) {
+	p.pos++
+	var b strings.Builder
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch c {
+		case '\\':
+			if p.pos+1 < len(p.src) {
+				b.WriteByte(p.src[p.pos+1])
+			}
+			p.pos += 2
+		case q:
+			p.pos++
+			return b.String(), true
+		default:
+			b.WriteByte(c)
+			p.pos++
+		}
+	}
+	return b.String(), false
+}
+
+func (p *beamParser) word() string {
+	start := p.pos
+	for p.pos < len(p.src) && isBeamWordChar(p.src[p.pos]) {
+		p.pos++
+	}
+	return p.src[start:p.pos]
+}
+
+func isBeamWordChar(c byte) bool {
+	return c == '_' || c == '@' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
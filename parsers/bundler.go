@@ -4,6 +4,8 @@ import (
 	"bufio"
 	"bytes"
 	"regexp"
+	"slices"
+	"strings"
 
 	"github.com/git-pkgs/resolve"
 )
@@ -32,6 +34,258 @@ func parseBundler(data []byte) ([]*resolve.Dep, error) {
 	return deps, nil
 }
 
+// gemfileLockEntryRe matches "name (version)" spec lines and "name (>= 1.0)" or
+// "name!" requirement lines, once indentation is removed.
+var gemfileLockEntryRe = regexp.MustCompile(`^([^\s!(]+)(!)?(?: \(([^)]*)\))?$`)
+
+// gemfileLockDefaultRemote is the remote that gets no repository_url qualifier.
+const gemfileLockDefaultRemote = "https://rubygems.org"
+
+// gemSpec is one entry under a GEM, GIT or PATH section's specs.
+type gemSpec struct {
+	name, version, platform string
+	source, location        string
+	qualifiers              map[string]string
+	deps                    []gemRequirement
+}
+
+type gemRequirement struct {
+	name, constraint string
+}
+
+// parseGemfileLock parses a Gemfile.lock. Specs are indented four spaces
+// under their source section and list their own requirements at six spaces;
+// DEPENDENCIES lists the direct deps. Platform-specific builds such as
+// "nokogiri (1.16.0-x86_64-linux)" get a platform qualifier and Target, and a
+// requirement on a gem with several platform builds lists each of them. Gems
+// from GIT sections get Source "git" and a vcs_url qualifier, gems from PATH
+// sections Source "path", and gems from a GEM remote other than rubygems.org a
+// repository_url qualifier.
+//
+// Gemfile.lock doesn't record groups. The Gemfile may be appended after the
+// lockfile, and direct deps in its groups then get a Scope: "dev" for
+// :development, "test" for :test, and the group name for any other group.
+func parseGemfileLock(data []byte) ([]*resolve.Dep, error) {
+	data, gemfile := splitGemfile(data)
+	groups := gemfileGroups(gemfile)
+
+	var specs []*gemSpec
+	byName := make(map[string][]*gemSpec)
+	var direct []gemRequirement
+
+	var section, remote, revision string
+	var current *gemSpec
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if line == "" {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		content := strings.TrimSpace(line)
+
+		if indent == 0 {
+			section, remote, revision, current = content, "", "", nil
+			continue
+		}
+
+		switch section {
+		case "GEM", "GIT", "PATH":
+			if indent == 2 { //nolint:mnd // source attributes
+				key, value, _ := strings.Cut(content, ": ")
+				switch key {
+				case "remote":
+					remote = value
+				case "revision":
+					revision = value
+				}
+				continue
+			}
+			m := gemfileLockEntryRe.FindStringSubmatch(content)
+			if m == nil {
+				continue
+			}
+			if indent == 4 { //nolint:mnd // spec line
+				current = newGemSpec(m[1], m[3], section, remote, revision)
+				specs = append(specs, current)
+				byName[current.name] = append(byName[current.name], current)
+			} else if current != nil {
+				current.deps = append(current.deps, gemRequirement{name: m[1], constraint: m[3]})
+			}
+		case "DEPENDENCIES":
+			if m := gemfileLockEntryRe.FindStringSubmatch(content); m != nil {
+				direct = append(direct, gemRequirement{name: m[1], constraint: m[3]})
+			}
+		}
+	}
+
+	// Without DEPENDENCIES, treat gems nothing requires as direct
+	if len(direct) == 0 {
+		required := make(map[string]bool)
+		for _, spec := range specs {
+			for _, dep := range spec.deps {
+				required[dep.name] = true
+			}
+		}
+		for _, spec := range specs {
+			if !required[spec.name] {
+				required[spec.name] = true
+				direct = append(direct, gemRequirement{name: spec.name})
+			}
+		}
+	}
+
+	seen := make(map[*gemSpec]bool)
+	var buildDeps func(req gemRequirement) []*resolve.Dep
+	buildDeps = func(req gemRequirement) []*resolve.Dep {
+		variants := byName[req.name]
+		if len(variants) == 0 {
+			// Default gems and bundler itself have no spec entry
+			return []*resolve.Dep{{
+				PURL:       resolve.MakePURL("gem", req.name, ""),
+				Name:       req.name,
+				Constraint: req.constraint,
+				Deps:       []*resolve.Dep{},
+			}}
+		}
+		var deps []*resolve.Dep
+		for _, spec := range variants {
+			dep := &resolve.Dep{
+				PURL:       resolve.MakePURLWithQualifiers("gem", spec.name, spec.version, spec.qualifiers),
+				Name:       spec.name,
+				Version:    spec.version,
+				Constraint: req.constraint,
+				Target:     spec.platform,
+				Source:     spec.source,
+				Location:   spec.location,
+				Deps:       []*resolve.Dep{},
+			}
+			if seen[spec] {
+				dep.Deduped = len(spec.deps) > 0
+			} else {
+				seen[spec] = true
+				for _, child := range spec.deps {
+					dep.Deps = append(dep.Deps, buildDeps(child)...)
+				}
+			}
+			deps = append(deps, dep)
+		}
+		return deps
+	}
+
+	var deps []*resolve.Dep
+	for _, req := range direct {
+		for _, dep := range buildDeps(req) {
+			dep.Scope = groups[req.name]
+			deps = append(deps, dep)
+		}
+	}
+	return deps, nil
+}
+
+// gemfileStartRe matches the unindented statements a Gemfile starts with,
+// which never appear in a Gemfile.lock, whose sections are upper case.
+var gemfileStartRe = regexp.MustCompile(`^(?:source|gem|gemspec|group|ruby|git_source|platforms?|path|git|eval_gemfile)\b`)
+
+var (
+	gemfileGemRe         = regexp.MustCompile(`^gem\s+["']([^"']+)["']`)
+	gemfileGroupBlockRe  = regexp.MustCompile(`^group\s+(.+?)\s+do\b`)
+	gemfileGroupOptionRe = regexp.MustCompile(`\bgroups?(?::|\s*=>)\s*(\[[^\]]*\]|:\w+|["']\w+["'])`)
+	gemfileGroupNameRe   = regexp.MustCompile(`\w+`)
+)
+
+// splitGemfile separates a Gemfile appended after the lockfile.
+func splitGemfile(data []byte) (lockfile, gemfile []byte) {
+	offset := 0
+	for _, line := range bytes.SplitAfter(data, []byte("\n")) {
+		if gemfileStartRe.Match(line) {
+			return data[:offset], data[offset:]
+		}
+		offset += len(line)
+	}
+	return data, nil
+}
+
+// gemfileGroups maps each gem declared in a Gemfile to the scope of its
+// group, from "group :development, :test do" blocks or "group: :test"
+// options. Gems in the default group are left out.
+func gemfileGroups(gemfile []byte) map[string]string {
+	groups := make(map[string]string)
+	var blocks [][]string // groups of each open "do" block; nil for other blocks
+	for _, line := range strings.Split(string(gemfile), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if line == "end" {
+			if len(blocks) > 0 {
+				blocks = blocks[:len(blocks)-1]
+			}
+			continue
+		}
+		if m := gemfileGroupBlockRe.FindStringSubmatch(line); m != nil {
+			blocks = append(blocks, gemfileGroupNameRe.FindAllString(m[1], -1))
+			continue
+		}
+		if m := gemfileGemRe.FindStringSubmatch(line); m != nil {
+			var names []string
+			if opt := gemfileGroupOptionRe.FindStringSubmatch(line); opt != nil {
+				names = gemfileGroupNameRe.FindAllString(opt[1], -1)
+			}
+			for i := len(blocks) - 1; i >= 0 && names == nil; i-- {
+				names = blocks[i]
+			}
+			if scope := gemfileGroupScope(names); scope != "" {
+				groups[m[1]] = scope
+			}
+			continue
+		}
+		if strings.HasSuffix(line, " do") || strings.Contains(line, " do |") {
+			blocks = append(blocks, nil)
+		}
+	}
+	return groups
+}
+
+// gemfileGroupScope returns the Scope for a gem in the given groups, using
+// the first one.
+func gemfileGroupScope(names []string) string {
+	if len(names) == 0 || slices.Contains(names, "default") {
+		return ""
+	}
+	switch names[0] {
+	case "development":
+		return "dev"
+	case "test":
+		return "test"
+	}
+	return names[0]
+}
+
+// newGemSpec builds a spec from a "name (version[-platform])" entry in the
+// given source section.
+func newGemSpec(name, version, section, remote, revision string) *gemSpec {
+	spec := &gemSpec{name: name, qualifiers: map[string]string{}}
+	spec.version, spec.platform, _ = strings.Cut(version, "-")
+	spec.qualifiers["platform"] = spec.platform
+
+	switch section {
+	case "GIT":
+		spec.source = "git"
+		spec.location = remote
+		spec.qualifiers["vcs_url"] = gitVCSURL(remote, revision)
+	case "PATH":
+		spec.source = "path"
+		spec.location = remote
+	default:
+		if strings.TrimSuffix(remote, "/") != gemfileLockDefaultRemote {
+			spec.qualifiers["repository_url"] = remote
+		}
+	}
+	return spec
+}
+
 func init() {
 	resolve.Register("bundler", "gem", parseBundler)
+	resolve.Register("bundler-lock", "gem", parseGemfileLock)
 }
@@ -3,6 +3,8 @@ package parsers
 import (
 	"encoding/json"
 	"fmt"
+	"regexp"
+	"strconv"
 	"strings"
 
 	"github.com/git-pkgs/resolve"
@@ -71,6 +73,7 @@ func parseCargo(data []byte) ([]*resolve.Dep, error) {
 			Deps:    []*resolve.Dep{},
 		}
 		if seen[id] {
+			dep.Deduped = true
 			return dep
 		}
 		seen[id] = true
@@ -96,6 +99,134 @@ func parseCargoID(id string) struct{ Name, Version string } {
 	return struct{ Name, Version string }{id, ""}
 }
 
+// cargoTreeRe matches "name v1.2.3" at the start of a `cargo tree` entry.
+// Trailing annotations such as "(proc-macro)", "(/path/to/crate)" or "(*)" are ignored.
+var cargoTreeRe = regexp.MustCompile(`^(\S+) v(\S+)`)
+
+// cargoTreeSections maps `cargo tree` section headers to dependency scopes.
+var cargoTreeSections = map[string]string{
+	"[build-dependencies]": "build",
+	"[dev-dependencies]":   "dev",
+}
+
+// parseCargoTree parses output from `cargo tree`, using either the default
+// box-drawing prefix or `--prefix depth`.
+// The unindented line of each tree is the workspace member itself; its children
+// are returned as direct deps. Entries marked "(*)" were already expanded
+// elsewhere; they have no children and are marked Deduped. Section headers
+// scope the entries listed beneath them, and a scoped entry's descendants
+// share its scope unless a nested header gives them another.
+func parseCargoTree(data []byte) ([]*resolve.Dep, error) {
+	type stackEntry struct {
+		dep   *resolve.Dep
+		depth int
+	}
+
+	var deps []*resolve.Dep
+	var stack []stackEntry
+	scopes := make(map[int]string) // entry depth -> scope from the enclosing header
+
+	for _, line := range strings.Split(string(data), "\n") {
+		depth, content := cargoTreeDepth(line)
+		if content == "" {
+			continue
+		}
+
+		// Headers sit at the depth of the package whose deps they introduce
+		if scope, ok := cargoTreeSections[content]; ok {
+			scopes[depth+1] = scope
+			continue
+		}
+
+		m := cargoTreeRe.FindStringSubmatch(content)
+		if m == nil {
+			continue
+		}
+
+		for d := range scopes {
+			if d > depth {
+				delete(scopes, d)
+			}
+		}
+
+		dep := &resolve.Dep{
+			PURL:    resolve.MakePURL("cargo", m[1], m[2]),
+			Name:    m[1],
+			Version: m[2],
+			Scope:   scopes[depth],
+			Deduped: strings.HasSuffix(content, "(*)"),
+			Deps:    []*resolve.Dep{},
+		}
+
+		for len(stack) > 0 && stack[len(stack)-1].depth >= depth {
+			stack = stack[:len(stack)-1]
+		}
+
+		switch {
+		case len(stack) == 0:
+			// Workspace member; its dependencies are what we return
+			clear(scopes)
+		case len(stack) == 1:
+			deps = append(deps, dep)
+		default:
+			parent := stack[len(stack)-1].dep
+			if dep.Scope == "" {
+				dep.Scope = parent.Scope
+			}
+			parent.Deps = append(parent.Deps, dep)
+		}
+
+		stack = append(stack, stackEntry{dep: dep, depth: depth})
+	}
+
+	return deps, nil
+}
+
+// cargoTreeDepth returns the nesting depth and content of a `cargo tree` line.
+// With `--prefix depth` the depth is a leading number; otherwise it is counted
+// from the box-drawing indentation, where the entry marker adds one level.
+func cargoTreeDepth(line string) (int, string) {
+	line = strings.TrimRight(line, " \t\r")
+
+	digits := 0
+	for digits < len(line) && line[digits] >= '0' && line[digits] <= '9' {
+		digits++
+	}
+	if digits > 0 {
+		depth, err := strconv.Atoi(line[:digits])
+		if err != nil {
+			return 0, ""
+		}
+		return depth, line[digits:]
+	}
+
+	opts := resolve.BoxDrawingOptions()
+	depth := 0
+	for {
+		found := false
+		for _, cont := range opts.Continuations {
+			if strings.HasPrefix(line, cont) {
+				depth++
+				line = line[len(cont):]
+				found = true
+				break
+			}
+		}
+		if !found {
+			break
+		}
+	}
+	for _, prefix := range opts.Prefixes {
+		if strings.HasPrefix(line, prefix) {
+			depth++
+			line = line[len(prefix):]
+			break
+		}
+	}
+	return depth, line
+}
+
 func init() {
 	resolve.Register("cargo", "cargo", parseCargo)
+	resolve.Register("cargo-tree", "cargo", parseCargoTree)
 }
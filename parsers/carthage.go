@@ -0,0 +1,65 @@
+package parsers
+
+import (
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/git-pkgs/resolve"
+)
+
+// cartfileResolvedRe matches `github "Alamofire/Alamofire" "5.8.1"` lines.
+var cartfileResolvedRe = regexp.MustCompile(`^(github|git|binary)\s+"([^"]+)"\s+"([^"]*)"`)
+
+// parseCartfileResolved parses a Cartfile.resolved, which pins each
+// dependency, direct or not, on its own line. GitHub dependencies
+// ("owner/repo") get pkg:github PURLs. Other git repositories get Source
+// "git" and a pkg:generic PURL named by host and path like Swift packages,
+// with a vcs_url qualifier; binary frameworks get Source "url" and a
+// pkg:generic PURL with the binary spec's URL as download_url. Names follow
+// Carthage, which names git and binary dependencies by the last part of
+// their URL.
+func parseCartfileResolved(data []byte) ([]*resolve.Dep, error) {
+	var deps []*resolve.Dep
+	for _, line := range strings.Split(string(data), "\n") {
+		m := cartfileResolvedRe.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			continue
+		}
+		kind, location, version := m[1], m[2], m[3]
+
+		// GitHub Enterprise dependencies are written as full URLs
+		if kind == "github" && !strings.Contains(location, "://") {
+			deps = append(deps, &resolve.Dep{
+				PURL:    resolve.MakePURL("github", location, version),
+				Name:    location,
+				Version: version,
+			})
+			continue
+		}
+
+		name := strings.TrimSuffix(path.Base(strings.TrimSuffix(location, "/")), ".git")
+		dep := &resolve.Dep{Name: name, Version: version, Location: location}
+		purlName := name
+		qualifiers := map[string]string{}
+		if kind == "binary" {
+			dep.Name = strings.TrimSuffix(name, ".json")
+			purlName = dep.Name
+			dep.Source = "url"
+			qualifiers["download_url"] = location
+		} else {
+			dep.Source = "git"
+			if p := gitRepoPath(location); p != "" {
+				purlName = p
+			}
+			qualifiers["vcs_url"] = gitVCSURL(location, version)
+		}
+		dep.PURL = resolve.MakePURLWithQualifiers("generic", purlName, version, qualifiers)
+		deps = append(deps, dep)
+	}
+	return deps, nil
+}
+
+func init() {
+	resolve.Register("carthage", "carthage", parseCartfileResolved)
+}
@@ -0,0 +1,203 @@
+package parsers
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"regexp"
+	"strings"
+
+	"github.com/git-pkgs/resolve"
+)
+
+// podfileLockEntryRe matches "Name (version)", "Name/Subspec (~> 1.0)" and
+// bare "Name" entries once the list marker and quotes are removed.
+var podfileLockEntryRe = regexp.MustCompile(`^(\S+)(?: \(([^)]*)\))?$`)
+
+// podDefaultRepo is the trunk spec repo, which gets no repository_url
+// qualifier. Older lockfiles name it by its git URL.
+const podDefaultRepo = "https://github.com/cocoapods/specs.git"
+
+// podSpec is one entry of a Podfile.lock's PODS list.
+type podSpec struct {
+	name, version string
+	deps          []podRequirement
+}
+
+type podRequirement struct {
+	name, constraint string
+}
+
+// parsePodfileLock parses a Podfile.lock. PODS lists every installed pod and
+// subspec with the requirements it declares, and DEPENDENCIES the pods the
+// Podfile asks for, which are the direct deps. Subspecs such as
+// "Firebase/Analytics" keep their full name and become the PURL subpath
+// (pkg:cocoapods/Firebase@10.18.0#Analytics). Pods from a spec repo other
+// than trunk carry a repository_url qualifier. Pods with a :git external
+// source get Source "git" and a vcs_url pinned to the commit or tag from
+// CHECKOUT OPTIONS, and :path and :podspec sources Source "path", or "url"
+// for a remote podspec or :http archive.
+func parsePodfileLock(data []byte) ([]*resolve.Dep, error) {
+	var specs []*podSpec
+	byName := make(map[string]*podSpec)
+	var direct []podRequirement
+	repos := make(map[string]string)               // root pod -> spec repo
+	external := make(map[string]map[string]string) // root pod -> :git, :path, ...
+	checkout := make(map[string]map[string]string) // root pod -> :commit, :tag
+	options := map[string]map[string]map[string]string{
+		"EXTERNAL SOURCES": external,
+		"CHECKOUT OPTIONS": checkout,
+	}
+
+	var section, key string
+	var current *podSpec
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \r")
+		if line == "" {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " "))
+		content := strings.TrimSpace(line)
+
+		if indent == 0 {
+			section, _, _ = strings.Cut(content, ":")
+			key, current = "", nil
+			continue
+		}
+
+		switch section {
+		case "PODS", "DEPENDENCIES":
+			item, ok := strings.CutPrefix(content, "- ")
+			if !ok {
+				continue
+			}
+			m := podfileLockEntryRe.FindStringSubmatch(strings.Trim(strings.TrimSuffix(item, ":"), `"'`))
+			if m == nil {
+				continue
+			}
+			switch {
+			case section == "DEPENDENCIES":
+				direct = append(direct, podRequirement{name: m[1], constraint: podConstraint(m[2])})
+			case indent == 2: //nolint:mnd // pod line
+				current = &podSpec{name: m[1], version: m[2]}
+				specs = append(specs, current)
+				byName[current.name] = current
+			case current != nil:
+				current.deps = append(current.deps, podRequirement{name: m[1], constraint: podConstraint(m[2])})
+			}
+		case "SPEC REPOS":
+			if indent == 2 { //nolint:mnd // repo line
+				key = strings.Trim(strings.TrimSuffix(content, ":"), `"'`)
+			} else if pod, ok := strings.CutPrefix(content, "- "); ok {
+				repos[strings.Trim(pod, `"'`)] = key
+			}
+		case "EXTERNAL SOURCES", "CHECKOUT OPTIONS":
+			byPod := options[section]
+			if indent == 2 { //nolint:mnd // pod line
+				key = strings.Trim(strings.TrimSuffix(content, ":"), `"'`)
+				byPod[key] = make(map[string]string)
+			} else if opt, value, ok := strings.Cut(content, ": "); ok && key != "" {
+				byPod[key][opt] = strings.Trim(value, `"'`)
+			}
+		}
+	}
+
+	// Without DEPENDENCIES, treat pods nothing requires as direct
+	if len(direct) == 0 {
+		required := make(map[string]bool)
+		for _, spec := range specs {
+			for _, dep := range spec.deps {
+				required[dep.name] = true
+			}
+		}
+		for _, spec := range specs {
+			if !required[spec.name] {
+				direct = append(direct, podRequirement{name: spec.name})
+			}
+		}
+	}
+
+	seen := make(map[*podSpec]bool)
+	var buildDep func(req podRequirement) *resolve.Dep
+	buildDep = func(req podRequirement) *resolve.Dep {
+		spec := byName[req.name]
+		if spec == nil {
+			return &resolve.Dep{
+				PURL:       resolve.MakePURL("cocoapods", req.name, ""),
+				Name:       req.name,
+				Constraint: req.constraint,
+				Deps:       []*resolve.Dep{},
+			}
+		}
+		root, _, _ := strings.Cut(spec.name, "/")
+		dep := &resolve.Dep{
+			Name:       spec.name,
+			Version:    spec.version,
+			Constraint: req.constraint,
+			Deps:       []*resolve.Dep{},
+		}
+		qualifiers := podSource(dep, external[root], checkout[root])
+		if repo := repos[root]; repo != "" && repo != "trunk" && !strings.EqualFold(repo, podDefaultRepo) {
+			qualifiers["repository_url"] = repo
+		}
+		dep.PURL = resolve.MakePURLWithQualifiers("cocoapods", spec.name, spec.version, qualifiers)
+		if seen[spec] {
+			dep.Deduped = len(spec.deps) > 0
+			return dep
+		}
+		seen[spec] = true
+		for _, child := range spec.deps {
+			dep.Deps = append(dep.Deps, buildDep(child))
+		}
+		return dep
+	}
+
+	var deps []*resolve.Dep
+	for _, req := range direct {
+		deps = append(deps, buildDep(req))
+	}
+	return deps, nil
+}
+
+// podConstraint returns a requirement's version constraint. DEPENDENCIES
+// entries for external sources read "(from `../MyLib`)" instead, which is
+// not a constraint.
+func podConstraint(s string) string {
+	if strings.HasPrefix(s, "from ") {
+		return ""
+	}
+	return s
+}
+
+// podSource sets Source and Location for a pod with an external source and
+// returns its PURL qualifiers.
+func podSource(dep *resolve.Dep, source, checkout map[string]string) map[string]string {
+	qualifiers := map[string]string{}
+	switch {
+	case source[":git"] != "":
+		dep.Source = "git"
+		dep.Location = source[":git"]
+		if ref := cmp.Or(checkout[":commit"], checkout[":tag"], source[":commit"], source[":tag"]); ref != "" {
+			qualifiers["vcs_url"] = gitVCSURL(dep.Location, ref)
+		}
+	case source[":path"] != "":
+		dep.Source = "path"
+		dep.Location = source[":path"]
+	case source[":podspec"] != "":
+		dep.Source = "path"
+		dep.Location = source[":podspec"]
+		if strings.Contains(dep.Location, "://") {
+			dep.Source = "url"
+		}
+	case source[":http"] != "":
+		dep.Source = "url"
+		dep.Location = source[":http"]
+		qualifiers["download_url"] = dep.Location
+	}
+	return qualifiers
+}
+
+func init() {
+	resolve.Register("cocoapods", "cocoapods", parsePodfileLock)
+}
@@ -1,6 +1,9 @@
 package parsers
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
 	"regexp"
 	"strings"
 
@@ -10,12 +13,45 @@ import (
 // composerPkgRe matches "vendor/package version" or "vendor/package version description".
 var composerPkgRe = regexp.MustCompile(`^(\S+/\S+)\s+(\S+)`)
 
-// parseComposer parses output from `composer show --tree`.
-// Top-level packages are on unindented lines without tree markers.
-// Their dependencies use box-drawing characters (├── └──).
+// composerNestedRe matches the content of a nested tree row: a package or
+// platform requirement, then a version or constraint and any description.
+var composerNestedRe = regexp.MustCompile(`(?i)^([a-z0-9_.-]+/[a-z0-9_.-]+|php(?:-[a-z0-9]+)?|hhvm|(?:ext|lib)-\S+|composer(?:-[a-z-]+)?)(?:\s+(.*))?$`)
+
+// composerTreeOptions matches the tree drawing of recent composer versions,
+// which puts no space between the branch and the package name:
+// "│  ├──psr/log ^1.0 || ^2.0". Older versions draw "│   ├── psr/log 3.0.0".
+var composerTreeOptions = resolve.TreeOptions{
+	Prefixes:      []string{"├──", "└──"},
+	Continuations: []string{"│  ", "   "},
+}
+
+// parseComposer parses output from `composer show --tree` or
+// `composer show --tree --format=json`, with or without --locked.
+// Top-level packages are on unindented lines without tree markers and show
+// resolved versions. Their dependencies use box-drawing characters and show
+// either a version or, in recent composer versions, the constraint the parent
+// requires. Constraints are kept in Constraint and Version is filled in from
+// top-level entries for the same package. The flat
+// `composer show` listing may be appended to supply versions for packages
+// that only appear nested; its column-aligned lines are not treated as roots.
+// Platform requirements (php, ext-*, lib-*) get Source "platform" and no PURL.
 func parseComposer(data []byte) ([]*resolve.Dep, error) {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		return parseComposerJSON(trimmed)
+	}
+
 	lines := strings.Split(string(data), "\n")
-	opts := resolve.BoxDrawingOptions()
+	listing := flatListingLines(lines)
+
+	resolved := make(map[string]string)
+	for _, line := range lines {
+		if line == "" || isBoxTreeLine(line) {
+			continue
+		}
+		if m := composerPkgRe.FindStringSubmatch(line); m != nil {
+			resolved[strings.ToLower(m[1])] = m[2]
+		}
+	}
 
 	var roots []*resolve.Dep
 
@@ -25,38 +61,18 @@ func parseComposer(data []byte) ([]*resolve.Dep, error) {
 	}
 	var stack []stackEntry
 
-	for _, line := range lines {
-		if line == "" {
+	for i, line := range lines {
+		if line == "" || listing[i] {
 			continue
 		}
 
-		// Check if line has tree markers (it's a sub-dependency)
-		hasTreeMarker := false
-		for _, prefix := range opts.Prefixes {
-			if strings.Contains(line, prefix) {
-				hasTreeMarker = true
-				break
-			}
-		}
-		for _, cont := range opts.Continuations {
-			if strings.HasPrefix(line, cont) {
-				hasTreeMarker = true
-				break
-			}
-		}
-
-		if !hasTreeMarker {
+		if !isBoxTreeLine(line) {
 			// Top-level package line
 			m := composerPkgRe.FindStringSubmatch(line)
 			if m == nil {
 				continue
 			}
-			dep := &resolve.Dep{
-				PURL:    resolve.MakePURL("packagist", m[1], m[2]),
-				Name:    m[1],
-				Version: m[2],
-				Deps:    []*resolve.Dep{},
-			}
+			dep := newComposerDep(m[1], m[2], "")
 			roots = append(roots, dep)
 			stack = []stackEntry{{dep: dep, depth: -1}}
 			continue
@@ -67,23 +83,31 @@ func parseComposer(data []byte) ([]*resolve.Dep, error) {
 		}
 
 		// Parse tree line for depth and content
+		opts := composerTreeOptions
+		if strings.Contains(line, "├── ") || strings.Contains(line, "└── ") {
+			opts = resolve.BoxDrawingOptions()
+		}
 		treeLines := resolve.ParseTreeLines([]string{line}, opts)
 		if len(treeLines) == 0 {
 			continue
 		}
-
 		tl := treeLines[0]
-		m := composerPkgRe.FindStringSubmatch(tl.Content)
+
+		content, circular := strings.CutSuffix(strings.TrimSpace(tl.Content), listingCircular)
+		m := composerNestedRe.FindStringSubmatch(strings.TrimSpace(content))
 		if m == nil {
 			continue
 		}
-
-		dep := &resolve.Dep{
-			PURL:    resolve.MakePURL("packagist", m[1], m[2]),
-			Name:    m[1],
-			Version: m[2],
-			Deps:    []*resolve.Dep{},
+		name, rest := m[1], strings.TrimSpace(m[2])
+		version, constraint := resolved[strings.ToLower(name)], ""
+		if isComposerConstraint(rest) {
+			constraint = rest
+		} else if fields := strings.Fields(rest); len(fields) > 0 {
+			// Older output shows the installed version, then the description
+			version = fields[0]
 		}
+		dep := newComposerDep(name, version, constraint)
+		dep.Deduped = circular
 
 		// Pop stack entries at same depth or deeper
 		for len(stack) > 1 && stack[len(stack)-1].depth >= tl.Depth {
@@ -98,6 +122,86 @@ func parseComposer(data []byte) ([]*resolve.Dep, error) {
 	return roots, nil
 }
 
+// composerJSONPackage is an entry of `composer show --tree --format=json`.
+// Nested entries carry the required constraint in Version.
+type composerJSONPackage struct {
+	Name     string                `json:"name"`
+	Version  string                `json:"version"`
+	Requires []composerJSONPackage `json:"requires"`
+}
+
+func parseComposerJSON(data []byte) ([]*resolve.Dep, error) {
+	var output struct {
+		Installed []composerJSONPackage `json:"installed"`
+		Locked    []composerJSONPackage `json:"locked"`
+	}
+	if err := json.Unmarshal(data, &output); err != nil {
+		return nil, fmt.Errorf("parsing composer output: %w", err)
+	}
+	packages := output.Installed
+	if packages == nil {
+		packages = output.Locked
+	}
+
+	resolved := make(map[string]string, len(packages))
+	for _, pkg := range packages {
+		resolved[strings.ToLower(pkg.Name)] = pkg.Version
+	}
+
+	var walk func(pkgs []composerJSONPackage) []*resolve.Dep
+	walk = func(pkgs []composerJSONPackage) []*resolve.Dep {
+		deps := []*resolve.Dep{}
+		for _, pkg := range pkgs {
+			dep := newComposerDep(pkg.Name, resolved[strings.ToLower(pkg.Name)], pkg.Version)
+			dep.Deps = walk(pkg.Requires)
+			deps = append(deps, dep)
+		}
+		return deps
+	}
+
+	var roots []*resolve.Dep
+	for _, pkg := range packages {
+		dep := newComposerDep(pkg.Name, pkg.Version, "")
+		dep.Deps = walk(pkg.Requires)
+		roots = append(roots, dep)
+	}
+	return roots, nil
+}
+
+// newComposerDep builds a Dep for a package or platform requirement.
+func newComposerDep(name, version, constraint string) *resolve.Dep {
+	dep := &resolve.Dep{
+		Name:       name,
+		Version:    version,
+		Constraint: constraint,
+		Deps:       []*resolve.Dep{},
+	}
+	if isComposerPlatform(name) {
+		dep.Source = "platform"
+	} else {
+		dep.PURL = resolve.MakePURL("packagist", name, version)
+	}
+	return dep
+}
+
+// isComposerConstraint reports whether the text after a nested package name
+// is a constraint such as "^7.2", "~1.0", ">=8.1", "^1.0 || ^2.0" or "*",
+// rather than an installed version and description.
+func isComposerConstraint(s string) bool {
+	if s == "" {
+		return false
+	}
+	return strings.ContainsAny(s[:1], "^~<>=!*") || strings.Contains(s, "||") ||
+		strings.Contains(strings.Fields(s)[0], "*")
+}
+
+// isComposerPlatform reports whether name is a platform package such as
+// php, ext-json, lib-curl or composer-runtime-api. Real packages are always
+// "vendor/name".
+func isComposerPlatform(name string) bool {
+	return !strings.Contains(name, "/")
+}
+
 func init() {
 	resolve.Register("composer", "packagist", parseComposer)
 }
@@ -3,7 +3,10 @@ package parsers
 import (
 	"bufio"
 	"bytes"
+	"encoding/json"
+	"fmt"
 	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/git-pkgs/resolve"
@@ -12,9 +15,15 @@ import (
 // conanRefRe matches package reference lines like "name/version" or "name/version@user/channel".
 var conanRefRe = regexp.MustCompile(`^(\S+)/(\S+?)(?:@|$)`)
 
-// parseConan parses output from `conan info .`.
-// Multi-line blocks per package, each starting with a package reference line.
+// parseConan parses output from Conan 1 `conan info .` or Conan 2
+// `conan graph info . --format=json`.
+// The Conan 1 text has multi-line blocks per package, each starting with a
+// package reference line, and is returned as a flat list.
 func parseConan(data []byte) ([]*resolve.Dep, error) {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		return parseConanGraph(trimmed)
+	}
+
 	var deps []*resolve.Dep
 	scanner := bufio.NewScanner(bytes.NewReader(data))
 
@@ -48,6 +57,118 @@ func parseConan(data []byte) ([]*resolve.Dep, error) {
 	return deps, nil
 }
 
+// conanNode is a node of the Conan 2 graph. Each node's dependencies map lists
+// its whole transitive closure keyed by node id, with "direct" set on the
+// edges it requires itself.
+type conanNode struct {
+	Name         string               `json:"name"`
+	Version      string               `json:"version"`
+	User         string               `json:"user"`
+	Channel      string               `json:"channel"`
+	Rrev         string               `json:"rrev"`
+	Context      string               `json:"context"`
+	Dependencies map[string]conanEdge `json:"dependencies"`
+}
+
+type conanEdge struct {
+	Direct bool `json:"direct"`
+	Build  bool `json:"build"`
+	Test   bool `json:"test"`
+}
+
+// parseConanGraph builds the tree from `conan graph info --format=json`,
+// following direct edges from the root node. Build requirements (tool_requires)
+// get Scope "build" and test requirements Scope "test". PURLs carry the
+// user, channel and rrev qualifiers from the purl-spec.
+func parseConanGraph(data []byte) ([]*resolve.Dep, error) {
+	var output struct {
+		Graph struct {
+			Nodes map[string]conanNode `json:"nodes"`
+			Root  map[string]string    `json:"root"`
+		} `json:"graph"`
+	}
+	if err := json.Unmarshal(data, &output); err != nil {
+		return nil, fmt.Errorf("parsing conan graph output: %w", err)
+	}
+	nodes := output.Graph.Nodes
+
+	rootID := "0"
+	for id := range output.Graph.Root {
+		rootID = id
+	}
+	root, ok := nodes[rootID]
+	if !ok {
+		return nil, fmt.Errorf("conan graph has no root node %q", rootID)
+	}
+
+	seen := make(map[string]bool)
+	var children func(node conanNode) []*resolve.Dep
+	var buildDep func(id, scope string) *resolve.Dep
+	buildDep = func(id, scope string) *resolve.Dep {
+		node := nodes[id]
+		// Requirements of a tool_requires are built for the build machine too
+		if scope == "" && node.Context == "build" {
+			scope = "build"
+		}
+		dep := &resolve.Dep{
+			PURL: resolve.MakePURLWithQualifiers("conan", node.Name, node.Version, map[string]string{
+				"user":    node.User,
+				"channel": node.Channel,
+				"rrev":    node.Rrev,
+			}),
+			Name:    node.Name,
+			Version: node.Version,
+			Scope:   scope,
+			Deps:    []*resolve.Dep{},
+		}
+		if seen[id] {
+			dep.Deduped = len(node.directIDs()) > 0
+			return dep
+		}
+		seen[id] = true
+		dep.Deps = children(node)
+		return dep
+	}
+	children = func(node conanNode) []*resolve.Dep {
+		deps := []*resolve.Dep{}
+		for _, id := range node.directIDs() {
+			if _, ok := nodes[id]; !ok {
+				continue
+			}
+			edge := node.Dependencies[id]
+			scope := ""
+			switch {
+			case edge.Build:
+				scope = "build"
+			case edge.Test:
+				scope = "test"
+			}
+			deps = append(deps, buildDep(id, scope))
+		}
+		return deps
+	}
+
+	return children(root), nil
+}
+
+// directIDs returns the ids of the nodes this node requires itself, in
+// numeric order ("1", "2", "10").
+func (n conanNode) directIDs() []string {
+	var ids []string
+	for id, edge := range n.Dependencies {
+		if edge.Direct {
+			ids = append(ids, id)
+		}
+	}
+	slices.SortFunc(ids, func(a, b string) int {
+		if len(a) != len(b) {
+			return len(a) - len(b)
+		}
+		return strings.Compare(a, b)
+	})
+	return ids
+}
+
 func init() {
 	resolve.Register("conan", "conan", parseConan)
 }
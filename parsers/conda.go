@@ -0,0 +1,254 @@
+package parsers
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/git-pkgs/resolve"
+)
+
+// condaPyPIChannel is the channel conda reports for packages pip installed
+// into the environment.
+const condaPyPIChannel = "pypi"
+
+// condaPackage holds the fields the conda PURL type carries as qualifiers.
+type condaPackage struct {
+	name, version, build, channel, subdir, ext string
+	checksum                                   string
+}
+
+// dep returns the Dep for a conda package, or a pkg:pypi Dep for packages
+// pip installed into the environment.
+func (p condaPackage) dep() *resolve.Dep {
+	dep := &resolve.Dep{Name: p.name, Version: p.version}
+	if p.channel == condaPyPIChannel {
+		dep.PURL = resolve.MakePURL("pypi", p.name, p.version)
+		return dep
+	}
+	dep.PURL = resolve.MakePURLWithQualifiers("conda", p.name, p.version, map[string]string{
+		"build":    p.build,
+		"channel":  p.channel,
+		"subdir":   p.subdir,
+		"type":     p.ext,
+		"checksum": p.checksum,
+	})
+	return dep
+}
+
+// parseConda parses output from `conda list --json`.
+// Format: [{"name", "version", "build_string", "channel", "platform"}, ...]
+// The build string, channel and platform become the build, channel and
+// subdir PURL qualifiers. Conda only gives short channel names for
+// conda.anaconda.org and repo.anaconda.com; packages from other hosts use
+// their base_url as the channel, as in conda-explicit. Packages pip installed
+// into the environment are listed with channel "pypi" and get pkg:pypi PURLs.
+func parseConda(data []byte) ([]*resolve.Dep, error) {
+	var packages []struct {
+		Name        string `json:"name"`
+		Version     string `json:"version"`
+		BuildString string `json:"build_string"`
+		Channel     string `json:"channel"`
+		BaseURL     string `json:"base_url"`
+		Platform    string `json:"platform"`
+	}
+	if err := json.Unmarshal(data, &packages); err != nil {
+		return nil, fmt.Errorf("parsing conda output: %w", err)
+	}
+
+	var deps []*resolve.Dep
+	for _, pkg := range packages {
+		if pkg.Name == "" {
+			continue
+		}
+		channel := pkg.Channel
+		if channel != condaPyPIChannel {
+			if u, err := url.Parse(pkg.BaseURL); err == nil && u.Scheme != "" && !isAnacondaHost(u.Host) {
+				channel = strings.TrimSuffix(pkg.BaseURL, "/")
+			}
+		}
+		deps = append(deps, condaPackage{
+			name:    pkg.Name,
+			version: pkg.Version,
+			build:   pkg.BuildString,
+			channel: channel,
+			subdir:  pkg.Platform,
+		}.dep())
+	}
+	return deps, nil
+}
+
+// parseCondaEnv parses the YAML written by `conda env export`. Entries under
+// dependencies look like "numpy=1.26.2=py311h64a7726_0", optionally prefixed
+// with "conda-forge::", and the nested pip list holds "requests==2.31.0"
+// requirements, which get pkg:pypi PURLs. With --from-history the entries
+// are the specs the user asked for; version ranges there are kept in
+// Constraint. The channels list only sets search order, so packages without
+// an explicit channel get no channel qualifier.
+func parseCondaEnv(data []byte) ([]*resolve.Dep, error) {
+	var deps []*resolve.Dep
+	section := ""
+	pipIndent := -1 // indentation of the "- pip:" entry while reading its list
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), " \r")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if line[0] != ' ' && line[0] != '-' {
+			section, _, _ = strings.Cut(trimmed, ":")
+			pipIndent = -1
+			continue
+		}
+		if section != "dependencies" {
+			continue
+		}
+		item, ok := strings.CutPrefix(trimmed, "- ")
+		if !ok {
+			continue
+		}
+		item = strings.Trim(item, `"'`)
+		indent := strings.Index(line, "-")
+		if item == "pip:" {
+			pipIndent = indent
+			continue
+		}
+		if pipIndent >= 0 && indent > pipIndent {
+			if dep := parseCondaPipRequirement(item); dep != nil {
+				deps = append(deps, dep)
+			}
+			continue
+		}
+		pipIndent = -1
+		deps = append(deps, parseCondaSpec(item))
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("parsing conda env export: %w", err)
+	}
+	return deps, nil
+}
+
+// parseCondaSpec parses a conda dependency such as "python=3.11.7=h955ad1f_0",
+// "conda-forge::scipy=1.11.4" or "numpy>=1.26".
+func parseCondaSpec(spec string) *resolve.Dep {
+	var pkg condaPackage
+	if channel, rest, ok := strings.Cut(spec, "::"); ok {
+		// "conda-forge/linux-64::numpy" names the subdir as well
+		pkg.channel, pkg.subdir, _ = strings.Cut(channel, "/")
+		spec = rest
+	}
+	end := strings.IndexAny(spec, "=<>!~ ")
+	if end < 0 {
+		pkg.name = spec
+		return pkg.dep()
+	}
+	pkg.name = spec[:end]
+	rest := strings.TrimSpace(spec[end:])
+	if strings.ContainsAny(rest, "<>!~*,|") || strings.HasPrefix(rest, "==") {
+		dep := pkg.dep()
+		dep.Constraint = rest
+		return dep
+	}
+	rest = strings.TrimPrefix(rest, "=")
+	pkg.version, pkg.build, _ = strings.Cut(rest, "=")
+	return pkg.dep()
+}
+
+// parseCondaPipRequirement parses an entry of the pip list, which is
+// normally "name==version". Editable and URL requirements have no version.
+func parseCondaPipRequirement(req string) *resolve.Dep {
+	if strings.HasPrefix(req, "-") {
+		return nil
+	}
+	pkg := condaPackage{channel: condaPyPIChannel}
+	if name, version, ok := strings.Cut(req, "=="); ok {
+		pkg.name, pkg.version = strings.TrimSpace(name), strings.TrimSpace(version)
+	} else {
+		name, _, _ := strings.Cut(req, "@")
+		pkg.name = strings.TrimSpace(name)
+	}
+	if pkg.name == "" {
+		return nil
+	}
+	return pkg.dep()
+}
+
+// parseCondaExplicit parses output from `conda list --explicit`, one package
+// URL per line after "@EXPLICIT", like
+// https://conda.anaconda.org/conda-forge/linux-64/numpy-1.26.2-py311h64a7726_0.conda#<md5>.
+// The file name gives the name, version, build and package type, and the
+// directories above it the subdir and channel. Channels on
+// conda.anaconda.org and repo.anaconda.com are named as conda reports them
+// ("conda-forge", "pkgs/main"); other channels keep their full URL. A hash
+// after "#" becomes the checksum qualifier.
+func parseCondaExplicit(data []byte) ([]*resolve.Dep, error) {
+	var deps []*resolve.Dep
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "@") {
+			continue
+		}
+		raw, hash, _ := strings.Cut(line, "#")
+		u, err := url.Parse(raw)
+		if err != nil || u.Scheme == "" {
+			continue
+		}
+
+		dir, file := path.Split(u.Path)
+		var pkg condaPackage
+		switch {
+		case strings.HasSuffix(file, ".conda"):
+			file, pkg.ext = strings.TrimSuffix(file, ".conda"), "conda"
+		case strings.HasSuffix(file, ".tar.bz2"):
+			file, pkg.ext = strings.TrimSuffix(file, ".tar.bz2"), "tar.bz2"
+		default:
+			continue
+		}
+		// Names can contain dashes but versions and builds can't
+		buildIdx := strings.LastIndex(file, "-")
+		if buildIdx <= 0 {
+			continue
+		}
+		versionIdx := strings.LastIndex(file[:buildIdx], "-")
+		if versionIdx <= 0 {
+			continue
+		}
+		pkg.name, pkg.version, pkg.build = file[:versionIdx], file[versionIdx+1:buildIdx], file[buildIdx+1:]
+
+		dir = strings.TrimSuffix(dir, "/")
+		channelPath, subdir := path.Split(dir)
+		pkg.subdir = subdir
+		channelPath = strings.Trim(channelPath, "/")
+		if isAnacondaHost(u.Host) {
+			pkg.channel = channelPath
+		} else {
+			pkg.channel = u.Scheme + "://" + u.Host + "/" + channelPath
+		}
+
+		if hash != "" {
+			if !strings.Contains(hash, ":") {
+				hash = "md5:" + hash
+			}
+			pkg.checksum = hash
+		}
+		deps = append(deps, pkg.dep())
+	}
+	return deps, nil
+}
+
+// isAnacondaHost reports whether conda names channels on host by their path
+// alone ("conda-forge", "pkgs/main").
+func isAnacondaHost(host string) bool {
+	return host == "conda.anaconda.org" || host == "repo.anaconda.com"
+}
+
+func init() {
+	resolve.Register("conda", "conda", parseConda)
+	resolve.Register("conda-env", "conda", parseCondaEnv)
+	resolve.Register("conda-explicit", "conda", parseCondaExplicit)
+}
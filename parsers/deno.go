@@ -3,85 +3,285 @@ package parsers
 import (
 	"encoding/json"
 	"fmt"
+	"net/url"
 	"strings"
 
 	"github.com/git-pkgs/resolve"
 )
 
+// denoModule is a module in the `deno info --json` graph.
+type denoModule struct {
+	Specifier    string `json:"specifier"`
+	NpmPackage   string `json:"npmPackage"`
+	Dependencies []struct {
+		Specifier  string `json:"specifier"`
+		NpmPackage string `json:"npmPackage"`
+		Code       *struct {
+			Specifier string `json:"specifier"`
+		} `json:"code"`
+		Type *struct {
+			Specifier string `json:"specifier"`
+		} `json:"type"`
+	} `json:"dependencies"`
+}
+
+// denoNpmPackage is an entry in the npmPackages section, keyed by
+// "name@version" with an optional "_peer@version" suffix.
+type denoNpmPackage struct {
+	Name         string   `json:"name"`
+	Version      string   `json:"version"`
+	Dependencies []string `json:"dependencies"`
+}
+
+// denoPackage groups the modules that belong to one published package.
+type denoPackage struct {
+	ecosystem, name, version string
+	url                      string
+	npmKey                   string
+	edges                    []denoEdge
+	linked                   map[*denoPackage]bool
+}
+
+type denoEdge struct {
+	pkg        *denoPackage
+	constraint string
+}
+
 // parseDeno parses output from `deno info --json`.
+// The module graph is collapsed into a package graph: modules from the local
+// project are the root, and each npm:, jsr:, deno.land or other remote package
+// becomes a node whose children are the packages its modules import. npm
+// packages take their dependencies from the npmPackages section. Packages the
+// local project doesn't reach are returned as direct deps so none are lost.
+// npm: packages get pkg:npm PURLs, jsr: packages pkg:jsr, deno.land modules
+// pkg:deno, and other remote imports pkg:generic with a download_url, so the
+// PURL types differ from the result's "deno" ecosystem.
 func parseDeno(data []byte) ([]*resolve.Dep, error) {
 	var output struct {
-		Modules []struct {
-			Specifier    string `json:"specifier"`
-			Dependencies []struct {
-				Specifier string `json:"specifier"`
-				Code      *struct {
-					Specifier string `json:"specifier"`
-				} `json:"code"`
-			} `json:"dependencies"`
-		} `json:"modules"`
+		Modules     []denoModule              `json:"modules"`
+		Redirects   map[string]string         `json:"redirects"`
+		NpmPackages map[string]denoNpmPackage `json:"npmPackages"`
 	}
 
 	if err := json.Unmarshal(data, &output); err != nil {
 		return nil, fmt.Errorf("parsing deno output: %w", err)
 	}
 
-	// Collect unique packages
-	seen := make(map[string]bool)
-	var deps []*resolve.Dep
+	modules := make(map[string]*denoModule, len(output.Modules))
+	for i := range output.Modules {
+		modules[output.Modules[i].Specifier] = &output.Modules[i]
+	}
+	npmByID := make(map[string]string, len(output.NpmPackages))
+	for key, pkg := range output.NpmPackages {
+		npmByID[pkg.Name+"@"+pkg.Version] = key
+	}
+
+	var order []*denoPackage
+	packages := make(map[string]*denoPackage)
+	lookup := func(ecosystem, name, version, location string) *denoPackage {
+		id := ecosystem + ":" + name + "@" + version
+		if pkg, ok := packages[id]; ok {
+			return pkg
+		}
+		pkg := &denoPackage{ecosystem: ecosystem, name: name, version: version, linked: make(map[*denoPackage]bool)}
+		if ecosystem == "generic" {
+			pkg.url = location
+		}
+		if ecosystem == "npm" {
+			pkg.npmKey = npmByID[name+"@"+version]
+		}
+		packages[id] = pkg
+		order = append(order, pkg)
+		return pkg
+	}
+	npmPackage := func(key string) *denoPackage {
+		if pkg, ok := output.NpmPackages[key]; ok {
+			return lookup("npm", pkg.Name, pkg.Version, "")
+		}
+		name, version := splitDenoNpmID(key)
+		return lookup("npm", name, version, "")
+	}
+	packageFor := func(spec, npmID string) *denoPackage {
+		spec = followDenoRedirects(spec, output.Redirects)
+		if mod, ok := modules[spec]; ok && mod.NpmPackage != "" {
+			npmID = mod.NpmPackage
+		}
+		if npmID != "" {
+			return npmPackage(npmID)
+		}
+		ecosystem, name, version := parseDenoSpecifier(spec)
+		if ecosystem == "" {
+			return nil
+		}
+		return lookup(ecosystem, name, version, spec)
+	}
+
+	project := &denoPackage{linked: make(map[*denoPackage]bool)}
+	link := func(from, to *denoPackage, constraint string) {
+		if to == nil || to == from || from.linked[to] {
+			return
+		}
+		from.linked[to] = true
+		from.edges = append(from.edges, denoEdge{pkg: to, constraint: constraint})
+	}
+
 	for _, mod := range output.Modules {
-		name, version := parseDenoSpecifier(mod.Specifier)
-		if name == "" || seen[name+"@"+version] {
-			continue
+		from := packageFor(mod.Specifier, mod.NpmPackage)
+		if from == nil {
+			if !strings.HasPrefix(mod.Specifier, "file:") {
+				continue
+			}
+			from = project
+		}
+		for _, dep := range mod.Dependencies {
+			target := dep.Specifier
+			if dep.Code != nil {
+				target = dep.Code.Specifier
+			} else if dep.Type != nil {
+				target = dep.Type.Specifier
+			}
+			_, _, constraint := parseDenoRegistrySpecifier(dep.Specifier)
+			link(from, packageFor(target, dep.NpmPackage), constraint)
+		}
+	}
+
+	seen := make(map[*denoPackage]bool)
+	var buildDep func(pkg *denoPackage, constraint string) *resolve.Dep
+	buildDep = func(pkg *denoPackage, constraint string) *resolve.Dep {
+		dep := &resolve.Dep{
+			PURL:       resolve.MakePURLWithQualifiers(pkg.ecosystem, pkg.name, pkg.version, map[string]string{"download_url": pkg.url}),
+			Name:       pkg.name,
+			Version:    pkg.version,
+			Constraint: constraint,
+			Deps:       []*resolve.Dep{},
+		}
+		if seen[pkg] {
+			dep.Deduped = len(pkg.edges) > 0 || pkg.npmKey != ""
+			return dep
+		}
+		seen[pkg] = true
+		if pkg.npmKey != "" {
+			for _, key := range output.NpmPackages[pkg.npmKey].Dependencies {
+				link(pkg, npmPackage(key), "")
+			}
+		}
+		for _, edge := range pkg.edges {
+			dep.Deps = append(dep.Deps, buildDep(edge.pkg, edge.constraint))
+		}
+		return dep
+	}
+
+	var deps []*resolve.Dep
+	for _, edge := range project.edges {
+		deps = append(deps, buildDep(edge.pkg, edge.constraint))
+	}
+	for _, pkg := range order {
+		if !seen[pkg] {
+			deps = append(deps, buildDep(pkg, ""))
 		}
-		seen[name+"@"+version] = true
-		deps = append(deps, &resolve.Dep{
-			PURL:    resolve.MakePURL("deno", name, version),
-			Name:    name,
-			Version: version,
-		})
 	}
 	return deps, nil
 }
 
-func parseDenoSpecifier(spec string) (string, string) {
-	// npm:express@4.18.2 or npm:@scope/pkg@1.0.0
-	if strings.HasPrefix(spec, "npm:") {
-		rest := spec[4:]
-		if idx := strings.LastIndex(rest, "@"); idx > 0 {
-			return rest[:idx], rest[idx+1:]
-		}
-		return rest, ""
-	}
-
-	// jsr:@std/path@0.200.0
-	if strings.HasPrefix(spec, "jsr:") {
-		rest := spec[4:]
-		if idx := strings.LastIndex(rest, "@"); idx > 0 {
-			return rest[:idx], rest[idx+1:]
-		}
-		return rest, ""
-	}
-
-	// https://deno.land/std@0.200.0/path/mod.ts -> name="std", version="0.200.0"
-	// https://deno.land/x/oak@12.0.0/mod.ts -> name="oak", version="12.0.0"
-	if strings.HasPrefix(spec, "https://deno.land/") {
-		rest := spec[len("https://deno.land/"):]
-		// Strip "x/" prefix if followed by more path
-		rest = strings.TrimPrefix(rest, "x/")
-		// Now rest is like "std@0.200.0/path/mod.ts" or "oak@12.0.0/mod.ts"
-		if idx := strings.Index(rest, "@"); idx > 0 {
-			name := rest[:idx]
-			versionAndPath := rest[idx+1:]
-			version := versionAndPath
-			if slashIdx := strings.Index(versionAndPath, "/"); slashIdx > 0 {
-				version = versionAndPath[:slashIdx]
-			}
-			return name, version
+// followDenoRedirects resolves a specifier through the redirects map, which
+// maps "npm:express@^4" to "npm:/express@4.18.2" and jsr: specifiers to
+// https://jsr.io URLs.
+func followDenoRedirects(spec string, redirects map[string]string) string {
+	for range len(redirects) {
+		next, ok := redirects[spec]
+		if !ok || next == spec {
+			break
+		}
+		spec = next
+	}
+	return spec
+}
+
+// splitDenoNpmID splits an npm package id like "@types/node@20.0.0" or
+// "react-dom@18.2.0_react@18.2.0" into name and version, dropping any peer
+// dependency suffix.
+func splitDenoNpmID(id string) (string, string) {
+	idx := strings.Index(id[min(1, len(id)):], "@")
+	if idx < 0 {
+		return id, ""
+	}
+	idx++
+	version, _, _ := strings.Cut(id[idx+1:], "_")
+	return id[:idx], version
+}
+
+// parseDenoRegistrySpecifier parses "npm:express@^4.18.2",
+// "npm:/@scope/pkg@1.0.0/sub/path" or "jsr:@std/path@^1.0.8" into ecosystem,
+// name and version, ignoring any subpath.
+func parseDenoRegistrySpecifier(spec string) (string, string, string) {
+	ecosystem, rest, ok := strings.Cut(spec, ":")
+	if !ok || (ecosystem != "npm" && ecosystem != "jsr") {
+		return "", "", ""
+	}
+	rest = strings.TrimPrefix(rest, "/")
+	start := 0
+	if strings.HasPrefix(rest, "@") {
+		if slash := strings.Index(rest, "/"); slash > 0 {
+			start = slash + 1
+		}
+	}
+	// The name runs to the version or, for "npm:pkg/sub", to the subpath
+	end := strings.IndexAny(rest[start:], "@/")
+	if end < 0 {
+		return ecosystem, rest, ""
+	}
+	end += start
+	if rest[end] != '@' {
+		return ecosystem, rest[:end], ""
+	}
+	version, _, _ := strings.Cut(rest[end+1:], "/")
+	return ecosystem, rest[:end], version
+}
+
+// parseDenoSpecifier identifies the package a module specifier belongs to and
+// returns its PURL type, name and version. Local files, node: builtins and
+// data: URLs return an empty ecosystem.
+func parseDenoSpecifier(spec string) (string, string, string) {
+	// npm:express@4.18.2, npm:/@scope/pkg@1.0.0, jsr:@std/path@0.200.0
+	if eco, name, version := parseDenoRegistrySpecifier(spec); eco != "" {
+		return eco, name, version
+	}
+
+	u, err := url.Parse(spec)
+	if err != nil || (u.Scheme != "https" && u.Scheme != "http") {
+		return "", "", ""
+	}
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+
+	switch u.Host {
+	case "jsr.io":
+		// https://jsr.io/@std/path/1.0.8/mod.ts
+		if len(segments) >= 3 && strings.HasPrefix(segments[0], "@") {
+			return "jsr", segments[0] + "/" + segments[1], segments[2]
+		}
+	case "deno.land":
+		// https://deno.land/std@0.200.0/path/mod.ts -> name="std", version="0.200.0"
+		// https://deno.land/x/oak@12.0.0/mod.ts -> name="oak", version="12.0.0"
+		rest := segments
+		if len(rest) > 1 && rest[0] == "x" {
+			rest = rest[1:]
+		}
+		if name, version, ok := strings.Cut(rest[0], "@"); ok && name != "" {
+			return "deno", name, version
 		}
 	}
 
-	return "", ""
+	// Other hosts (esm.sh, unpkg, raw GitHub...): use the first "name@version"
+	// path segment when there is one, otherwise the module URL itself.
+	for i, seg := range segments {
+		if i > 0 && strings.HasPrefix(segments[i-1], "@") {
+			seg = segments[i-1] + "/" + seg
+		}
+		if idx := strings.LastIndex(seg, "@"); idx > 0 {
+			return "generic", u.Host + "/" + seg[:idx], seg[idx+1:]
+		}
+	}
+	return "generic", u.Host + u.Path, ""
 }
 
 func init() {
@@ -62,6 +62,7 @@ func parseGomod(data []byte) ([]*resolve.Dep, error) {
 			Deps:    []*resolve.Dep{},
 		}
 		if seen[mod] {
+			dep.Deduped = true
 			return dep
 		}
 		seen[mod] = true
@@ -0,0 +1,343 @@
+package parsers
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/git-pkgs/resolve"
+)
+
+// haskellPackage is a node in a stack or cabal package graph. dep is copied
+// for each place the package appears in the tree.
+type haskellPackage struct {
+	dep   resolve.Dep
+	local bool
+	edges []haskellEdge
+}
+
+type haskellEdge struct {
+	id    string
+	scope string
+}
+
+// parseStack parses output from `stack ls dependencies json`.
+// Format: [{"name", "version", "license", "location", "dependencies"}, ...]
+// where dependencies names the packages each one depends on. Project packages
+// are the roots: with a single project its dependencies are returned directly,
+// otherwise each project package is a root with Source "workspace" and no
+// PURL. Packages from git or hg get that Source and a vcs_url qualifier, and
+// archives Source "url" with a download_url. Output without dependency lists, from older
+// stack versions, is returned as a flat list.
+func parseStack(data []byte) ([]*resolve.Dep, error) {
+	var packages []struct {
+		Name     string `json:"name"`
+		Version  string `json:"version"`
+		License  string `json:"license"`
+		Location *struct {
+			Type   string `json:"type"`
+			URL    string `json:"url"`
+			Commit string `json:"commit"`
+		} `json:"location"`
+		Dependencies []string `json:"dependencies"`
+	}
+	if err := json.Unmarshal(data, &packages); err != nil {
+		return nil, fmt.Errorf("parsing stack output: %w", err)
+	}
+
+	var nodes []*haskellPackage
+	tree := false
+	for _, pkg := range packages {
+		if pkg.Name == "" {
+			continue
+		}
+		node := &haskellPackage{dep: resolve.Dep{Name: pkg.Name, Version: pkg.Version, License: pkg.License}}
+		qualifiers := map[string]string{}
+		if loc := pkg.Location; loc != nil {
+			switch loc.Type {
+			case "project package":
+				node.local = true
+				node.dep.Source = "workspace"
+				node.dep.Location = loc.URL
+			case "git", "hg":
+				node.dep.Source = loc.Type
+				node.dep.Location = loc.URL
+				if loc.Commit != "" {
+					qualifiers["vcs_url"] = vcsURL(loc.Type, loc.URL, loc.Commit)
+				}
+			case "archive":
+				node.dep.Source = "url"
+				node.dep.Location = loc.URL
+				qualifiers["download_url"] = loc.URL
+			}
+		}
+		if !node.local {
+			node.dep.PURL = resolve.MakePURLWithQualifiers("hackage", pkg.Name, pkg.Version, qualifiers)
+		}
+		for _, name := range pkg.Dependencies {
+			node.edges = append(node.edges, haskellEdge{id: name})
+		}
+		tree = tree || pkg.Dependencies != nil
+		nodes = append(nodes, node)
+	}
+
+	if !tree {
+		var deps []*resolve.Dep
+		for _, node := range nodes {
+			dep := node.dep
+			deps = append(deps, &dep)
+		}
+		return deps, nil
+	}
+	return buildHaskellTree(nodes, func(n *haskellPackage) string { return n.dep.Name }), nil
+}
+
+// cabalUnit is an entry in the install-plan of cabal's plan.json. Packages
+// built per component have one unit per component with its own depends;
+// others have a single unit with a components map.
+type cabalUnit struct {
+	Type       string   `json:"type"`
+	ID         string   `json:"id"`
+	PkgName    string   `json:"pkg-name"`
+	PkgVersion string   `json:"pkg-version"`
+	Style      string   `json:"style"`
+	Component  string   `json:"component-name"`
+	Depends    []string `json:"depends"`
+	ExeDepends []string `json:"exe-depends"`
+	Components map[string]struct {
+		Depends    []string `json:"depends"`
+		ExeDepends []string `json:"exe-depends"`
+	} `json:"components"`
+	PkgSrc *struct {
+		Type string `json:"type"`
+		Path string `json:"path"`
+		URI  string `json:"uri"`
+		Repo *struct {
+			URI string `json:"uri"`
+		} `json:"repo"`
+		SourceRepo *struct {
+			Type     string `json:"type"`
+			Location string `json:"location"`
+			Tag      string `json:"tag"`
+		} `json:"source-repo"`
+	} `json:"pkg-src"`
+}
+
+// cabalDefaultRepo is the Hackage repository that gets no repository_url.
+const cabalDefaultRepo = "hackage.haskell.org"
+
+// parseCabalPlan parses cabal's dist-newstyle/cache/plan.json.
+// Units in the install-plan are collapsed into packages, and each package's
+// dependencies are the union of its components' depends. Dependencies only
+// test or benchmark components use get Scope "test" or "bench", and build
+// tools from exe-depends Scope "build". Local packages are the roots, as for
+// stack; packages from source repositories get Source "git" and a vcs_url,
+// local or remote tarballs Source "path" or "url", and packages from a
+// repository other than Hackage a repository_url qualifier. Local packages
+// and tarballs get no PURL. GHC's
+// pre-existing packages such as base are included like any other.
+func parseCabalPlan(data []byte) ([]*resolve.Dep, error) {
+	var plan struct {
+		InstallPlan []cabalUnit `json:"install-plan"`
+	}
+	if err := json.Unmarshal(data, &plan); err != nil {
+		return nil, fmt.Errorf("parsing cabal plan.json: %w", err)
+	}
+
+	var nodes []*haskellPackage
+	byKey := make(map[string]*haskellPackage)
+	unitKey := make(map[string]string, len(plan.InstallPlan))
+	for _, unit := range plan.InstallPlan {
+		key := unit.PkgName + "-" + unit.PkgVersion
+		unitKey[unit.ID] = key
+		if _, ok := byKey[key]; ok {
+			continue
+		}
+		node := newCabalPackage(unit)
+		byKey[key] = node
+		nodes = append(nodes, node)
+	}
+
+	// Scope each edge by the components that need it; a runtime use wins
+	scopes := make(map[string]map[string]string)
+	addEdges := func(key, component string, depends, exeDepends []string) {
+		if scopes[key] == nil {
+			scopes[key] = make(map[string]string)
+		}
+		node := byKey[key]
+		add := func(unitID, scope string) {
+			target, ok := unitKey[unitID]
+			if !ok || target == key {
+				return
+			}
+			current, seen := scopes[key][target]
+			if !seen {
+				node.edges = append(node.edges, haskellEdge{id: target})
+			}
+			if !seen || cabalScopeRank(scope) < cabalScopeRank(current) {
+				scopes[key][target] = scope
+			}
+		}
+		scope := cabalComponentScope(component)
+		for _, id := range depends {
+			add(id, scope)
+		}
+		for _, id := range exeDepends {
+			add(id, "build")
+		}
+	}
+	for _, unit := range plan.InstallPlan {
+		key := unitKey[unit.ID]
+		addEdges(key, unit.Component, unit.Depends, unit.ExeDepends)
+		for _, name := range slices.Sorted(maps.Keys(unit.Components)) {
+			comp := unit.Components[name]
+			addEdges(key, name, comp.Depends, comp.ExeDepends)
+		}
+	}
+	for key, node := range byKey {
+		for i, edge := range node.edges {
+			node.edges[i].scope = scopes[key][edge.id]
+		}
+	}
+
+	return buildHaskellTree(nodes, func(n *haskellPackage) string { return n.dep.Name + "-" + n.dep.Version }), nil
+}
+
+func newCabalPackage(unit cabalUnit) *haskellPackage {
+	node := &haskellPackage{dep: resolve.Dep{Name: unit.PkgName, Version: unit.PkgVersion}}
+	qualifiers := map[string]string{}
+	if src := unit.PkgSrc; src != nil {
+		switch src.Type {
+		case "local":
+			node.local = unit.Style == "local"
+			node.dep.Source = "workspace"
+			node.dep.Location = src.Path
+		case "local-tarball":
+			node.dep.Source = "path"
+			node.dep.Location = src.Path
+		case "remote-tarball":
+			node.dep.Source = "url"
+			node.dep.Location = src.URI
+			qualifiers["download_url"] = src.URI
+		case "source-repo":
+			if repo := src.SourceRepo; repo != nil {
+				node.dep.Source = repo.Type
+				node.dep.Location = repo.Location
+				if repo.Tag != "" {
+					qualifiers["vcs_url"] = vcsURL(repo.Type, repo.Location, repo.Tag)
+				}
+			}
+		case "repo-tar":
+			if src.Repo != nil && !strings.Contains(src.Repo.URI, cabalDefaultRepo) {
+				qualifiers["repository_url"] = strings.TrimSuffix(src.Repo.URI, "/")
+			}
+		}
+	}
+	if node.dep.Source != "workspace" && node.dep.Source != "path" {
+		node.dep.PURL = resolve.MakePURLWithQualifiers("hackage", unit.PkgName, unit.PkgVersion, qualifiers)
+	}
+	return node
+}
+
+// cabalComponentScope returns the scope of dependencies of a component such
+// as "lib", "exe:my-app", "test:spec" or "bench:speed".
+func cabalComponentScope(component string) string {
+	switch {
+	case strings.HasPrefix(component, "test:"):
+		return "test"
+	case strings.HasPrefix(component, "bench:"):
+		return "bench"
+	case component == "setup":
+		return "build"
+	}
+	return ""
+}
+
+// cabalScopeRank orders scopes so that an edge used at runtime by any
+// component stays unscoped.
+func cabalScopeRank(scope string) int {
+	switch scope {
+	case "":
+		return 0
+	case "build":
+		return 1
+	case "test":
+		return 2
+	}
+	return 3
+}
+
+// buildHaskellTree turns a package graph into a tree rooted at the local
+// packages. Without local packages, packages nothing depends on are the
+// roots. Packages the roots don't reach are appended as direct deps.
+func buildHaskellTree(nodes []*haskellPackage, idOf func(*haskellPackage) string) []*resolve.Dep {
+	byID := make(map[string]*haskellPackage, len(nodes))
+	required := make(map[string]bool)
+	var locals []*haskellPackage
+	for _, node := range nodes {
+		byID[idOf(node)] = node
+		if node.local {
+			locals = append(locals, node)
+		}
+	}
+	// Drop edges to packages the output doesn't list, like stack's rts
+	for _, node := range nodes {
+		node.edges = slices.DeleteFunc(node.edges, func(edge haskellEdge) bool {
+			return byID[edge.id] == nil
+		})
+		for _, edge := range node.edges {
+			required[edge.id] = true
+		}
+	}
+
+	seen := make(map[*haskellPackage]bool)
+	var buildDep func(node *haskellPackage, scope string) *resolve.Dep
+	buildDep = func(node *haskellPackage, scope string) *resolve.Dep {
+		dep := node.dep
+		dep.Scope = scope
+		dep.Deps = []*resolve.Dep{}
+		if seen[node] {
+			dep.Deduped = len(node.edges) > 0
+			return &dep
+		}
+		seen[node] = true
+		for _, edge := range node.edges {
+			dep.Deps = append(dep.Deps, buildDep(byID[edge.id], edge.scope))
+		}
+		return &dep
+	}
+
+	deps := []*resolve.Dep{}
+	switch {
+	case len(locals) == 1:
+		seen[locals[0]] = true
+		for _, edge := range locals[0].edges {
+			deps = append(deps, buildDep(byID[edge.id], edge.scope))
+		}
+	case len(locals) > 1:
+		for _, local := range locals {
+			// Expand each project even if another project depends on it
+			delete(seen, local)
+			deps = append(deps, buildDep(local, ""))
+		}
+	default:
+		for _, node := range nodes {
+			if !required[idOf(node)] {
+				deps = append(deps, buildDep(node, ""))
+			}
+		}
+	}
+	for _, node := range nodes {
+		if !seen[node] {
+			deps = append(deps, buildDep(node, ""))
+		}
+	}
+	return deps
+}
+
+func init() {
+	resolve.Register("stack", "hackage", parseStack)
+	resolve.Register("cabal", "hackage", parseCabalPlan)
+}
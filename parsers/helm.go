@@ -3,6 +3,7 @@ package parsers
 import (
 	"bufio"
 	"bytes"
+	"path"
 	"strings"
 
 	"github.com/git-pkgs/resolve"
@@ -10,35 +11,170 @@ import (
 
 // parseHelm parses output from `helm dependency list`.
 // Format: tab-separated table with header: NAME VERSION REPOSITORY STATUS
+// The repository becomes a repository_url qualifier, with the scheme dropped
+// for oci:// registries. Charts from file:// repositories are marked as path
+// sources and get no PURL.
+// Any STATUS other than "ok" or "unpacked" is reported as a diagnostic.
+// Version ranges, common in subcharts ("2.x.x"), are kept in Constraint.
+//
+// Umbrella charts can include the listings of their vendored subcharts by
+// appending them after "==> charts/<name>-<version>.tgz" header lines, as in
+//
+//	helm dependency list .; for c in charts/*.tgz; do echo "==> $c"; helm dependency list "$c"; done
+//
+// Each subchart's dependencies are then nested under the entry with the
+// archive's chart name, at any depth. An archive whose version differs from
+// the entry's gets a "version-mismatch" diagnostic on that entry.
 func parseHelm(data []byte) ([]*resolve.Dep, error) {
 	var deps []*resolve.Dep
+	current := &deps
 	scanner := bufio.NewScanner(bytes.NewReader(data))
-	first := true
 	for scanner.Scan() {
-		line := scanner.Text()
-		if first {
-			first = false
-			continue // skip header
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "NAME") || strings.HasPrefix(line, "WARNING") {
+			continue
 		}
-		line = strings.TrimSpace(line)
-		if line == "" {
+		if chart, ok := strings.CutPrefix(line, "==> "); ok {
+			current = nil
+			if parent, version := findHelmSubchart(deps, chart); parent != nil {
+				if parent.Version != "" && version != "" && version != parent.Version {
+					parent.Diagnostics = append(parent.Diagnostics, resolve.Diagnostic{
+						Kind:    "version-mismatch",
+						Message: "vendored " + chart + " is version " + version,
+					})
+				}
+				parent.Deps = []*resolve.Dep{}
+				current = &parent.Deps
+			}
 			continue
 		}
-		fields := strings.Fields(line)
-		if len(fields) < 2 { //nolint:mnd // name + version
+		if current == nil {
 			continue
 		}
-		name := fields[0]
-		version := fields[1]
-		deps = append(deps, &resolve.Dep{
-			PURL:    resolve.MakePURL("helm", name, version),
-			Name:    name,
-			Version: version,
-		})
+		if dep := parseHelmRow(line); dep != nil {
+			*current = append(*current, dep)
+		}
 	}
 	return deps, nil
 }
 
+// Columns of the `helm dependency list` table.
+const (
+	helmNameColumn = iota
+	helmVersionColumn
+	helmRepositoryColumn
+	helmStatusColumn
+	helmColumns
+)
+
+// parseHelmRow parses one row of the dependency table. Columns are separated
+// by tabs; STATUS may contain spaces ("wrong version").
+func parseHelmRow(line string) *resolve.Dep {
+	var fields []string
+	if strings.Contains(line, "\t") {
+		for _, f := range strings.Split(line, "\t") {
+			fields = append(fields, strings.TrimSpace(f))
+		}
+	} else {
+		fields = strings.Fields(line)
+		if len(fields) > helmColumns {
+			fields = append(fields[:helmStatusColumn], strings.Join(fields[helmStatusColumn:], " "))
+		}
+	}
+	if len(fields) <= helmVersionColumn {
+		return nil
+	}
+
+	name, version := fields[helmNameColumn], fields[helmVersionColumn]
+	var repo, status string
+	if len(fields) > helmRepositoryColumn {
+		repo = fields[helmRepositoryColumn]
+	}
+	if len(fields) > helmStatusColumn {
+		status = fields[helmStatusColumn]
+	}
+
+	// VERSION is whatever Chart.yaml asks for, which may be a range
+	var constraint string
+	if isHelmVersionRange(version) {
+		version, constraint = "", version
+	}
+	dep := &resolve.Dep{Name: name, Version: version, Constraint: constraint}
+	var repoURL string
+	switch {
+	case strings.HasPrefix(repo, "oci://"):
+		repoURL = strings.TrimPrefix(repo, "oci://")
+	case strings.HasPrefix(repo, "file://"):
+		dep.Source = "path"
+		dep.Location = strings.TrimPrefix(repo, "file://")
+	case strings.Contains(repo, "://"):
+		repoURL = repo
+	}
+	if dep.Source != "path" {
+		dep.PURL = resolve.MakePURLWithQualifiers("helm", name, version, map[string]string{"repository_url": repoURL})
+	}
+
+	if status != "" && status != "ok" && status != "unpacked" {
+		dep.Diagnostics = append(dep.Diagnostics, resolve.Diagnostic{
+			Kind:    strings.ReplaceAll(status, " ", "-"),
+			Message: status,
+		})
+	}
+	return dep
+}
+
+// isHelmVersionRange reports whether a Chart.yaml version is a semver range
+// such as "2.x.x", "^1.2.0" or ">=1.0.0 <2.0.0" rather than an exact version.
+func isHelmVersionRange(version string) bool {
+	if strings.ContainsAny(version, "^~<>=*|, ") {
+		return true
+	}
+	core, _, _ := strings.Cut(version, "-")
+	for _, part := range strings.Split(core, ".") {
+		if part == "x" || part == "X" {
+			return true
+		}
+	}
+	return false
+}
+
+// findHelmSubchart returns the dependency a vendored chart archive such as
+// "charts/postgresql-12.1.9.tgz" belongs to, along with the archive's
+// version. Entries whose version matches are preferred; otherwise the first
+// entry with the chart's name is used, searching subcharts too.
+func findHelmSubchart(deps []*resolve.Dep, chart string) (*resolve.Dep, string) {
+	base := strings.TrimSuffix(path.Base(chart), ".tgz")
+	var match *resolve.Dep
+	var version string
+	var walk func(deps []*resolve.Dep) bool
+	walk = func(deps []*resolve.Dep) bool {
+		for _, dep := range deps {
+			if rest, ok := strings.CutPrefix(base, dep.Name); ok && (rest == "" || isHelmVersionSuffix(rest)) {
+				v := strings.TrimPrefix(rest, "-")
+				if match == nil || v == dep.Version {
+					match, version = dep, v
+				}
+				if v == dep.Version {
+					return true
+				}
+			}
+			if walk(dep.Deps) {
+				return true
+			}
+		}
+		return false
+	}
+	walk(deps)
+	return match, version
+}
+
+// isHelmVersionSuffix reports whether s is the "-<version>" that follows the
+// chart name in an archive name, so "redis-cluster-9.0.0" isn't taken for
+// a "redis" archive.
+func isHelmVersionSuffix(s string) bool {
+	return len(s) > 1 && s[0] == '-' && s[1] >= '0' && s[1] <= '9'
+}
+
 func init() {
 	resolve.Register("helm", "helm", parseHelm)
 }
@@ -0,0 +1,38 @@
+package parsers
+
+import (
+	"strings"
+
+	"github.com/git-pkgs/resolve"
+)
+
+// hexDefaultRepo is the public Hex repository.
+const hexDefaultRepo = "hexpm"
+
+// setHexPackage sets dep's PURL for package pkg from a Hex repository.
+// Packages from a private organisation ("hexpm:myorg") use the organisation
+// as the PURL namespace, as the purl-spec describes for hex. Packages from
+// another repository get Source "registry" with the repository name in
+// Location, since lockfiles only record the name the project configured.
+func setHexPackage(dep *resolve.Dep, pkg, repo string) {
+	name := pkg
+	if org, ok := strings.CutPrefix(repo, hexDefaultRepo+":"); ok && org != "" {
+		name = org + "/" + pkg
+	} else if repo != "" && repo != hexDefaultRepo {
+		dep.Source = "registry"
+		dep.Location = repo
+	}
+	dep.PURL = resolve.MakePURL("hex", name, dep.Version)
+}
+
+// setHexGit marks dep as checked out from a git repository, adding a vcs_url
+// qualifier when the commit or tag is known.
+func setHexGit(dep *resolve.Dep, url, ref string) {
+	dep.Source = "git"
+	dep.Location = url
+	qualifiers := map[string]string{}
+	if url != "" && ref != "" {
+		qualifiers["vcs_url"] = gitVCSURL(url, ref)
+	}
+	dep.PURL = resolve.MakePURLWithQualifiers("hex", dep.Name, dep.Version, qualifiers)
+}
@@ -1,49 +1,209 @@
 package parsers
 
 import (
+	"fmt"
 	"regexp"
 	"strings"
 
 	"github.com/git-pkgs/resolve"
 )
 
-// leinPkgRe matches "[group/name \"version\"]" or "[name \"version\"]".
-var leinPkgRe = regexp.MustCompile(`\[(\S+)\s+"([^"]+)"\]`)
+// leinVectorRe matches a dependency vector: [group/name "version" & options].
+var leinVectorRe = regexp.MustCompile(`^\[(\S+)\s+"([^"]*)"(.*)\]$`)
+
+// leinOptionRe matches the vector options that change which artifact or
+// scope a dependency refers to.
+var leinOptionRe = regexp.MustCompile(`:(classifier|extension|scope)\s+"([^"]*)"`)
+
+// leinConflictsHeader starts the block pedantic checks print before the tree.
+const leinConflictsHeader = "Possibly confusing dependencies found:"
+
+// leinExclusionsHeader starts the suggested exclusions after the conflicts.
+const leinExclusionsHeader = "Consider using these exclusions:"
+
+// leinConflict is one "X overrides Y" entry from the conflicts block. Each
+// chain is the path of vectors from a top-level dependency to the artifact.
+type leinConflict struct {
+	accepted   []*resolve.Dep
+	overridden [][]*resolve.Dep
+}
 
 // parseLein parses output from `lein deps :tree`.
-// Bracket-indented format: [group/name "version"] with increasing space indentation.
+// Bracket-indented format: [group/name "version"] with increasing space
+// indentation. Vectors may carry :exclusions, which are skipped, and
+// :classifier, :extension and :scope options; the first two become PURL
+// qualifiers. The "Possibly confusing dependencies found" block printed with
+// :pedantic? :warn doesn't describe the tree, so each override in it is
+// attached as a "conflict" diagnostic to the accepted dependency instead, and
+// the suggested exclusions after it are ignored.
 func parseLein(data []byte) ([]*resolve.Dep, error) {
-	lines := strings.Split(string(data), "\n")
 	var treeLines []resolve.TreeLine
+	var conflicts []*leinConflict
+	var current *leinConflict
+	mode := ""
+	overrides := false
+
+	for _, line := range strings.Split(string(data), "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch trimmed {
+		case leinConflictsHeader:
+			mode, current = "conflicts", nil
+			continue
+		case leinExclusionsHeader:
+			mode = "exclusions"
+			continue
+		case "":
+			if mode == "exclusions" {
+				mode = ""
+			}
+			continue
+		}
+
+		// Conflict chains and suggestions start in the first column;
+		// anything else ends the warning block.
+		if mode != "" && !strings.HasPrefix(line, "[") && trimmed != "overrides" && trimmed != "and" {
+			mode = ""
+		}
+		switch mode {
+		case "conflicts":
+			switch trimmed {
+			case "overrides", "and":
+				overrides = true
+			default:
+				chain := parseLeinChain(trimmed)
+				if len(chain) == 0 {
+					continue
+				}
+				if current == nil || !overrides {
+					current = &leinConflict{accepted: chain}
+					conflicts = append(conflicts, current)
+				} else {
+					current.overridden = append(current.overridden, chain)
+				}
+				overrides = false
+			}
+			continue
+		case "exclusions":
+			continue
+		}
 
-	for _, line := range lines {
-		if strings.TrimSpace(line) == "" {
+		if !strings.HasPrefix(trimmed, "[") {
 			continue
 		}
-		m := leinPkgRe.FindStringSubmatch(line)
-		if m == nil {
+		// Depth from leading whitespace; each level is typically 2 spaces
+		depth := (len(line) - len(strings.TrimLeft(line, " "))) / 2
+		treeLines = append(treeLines, resolve.TreeLine{Depth: depth, Content: trimmed})
+	}
+
+	deps := resolve.BuildDepTree(treeLines, parseLeinVector)
+	for _, c := range conflicts {
+		accepted := c.accepted[len(c.accepted)-1]
+		dep := findLeinDep(deps, accepted.Name, accepted.Version)
+		if dep == nil {
 			continue
 		}
+		for _, chain := range c.overridden {
+			dep.Diagnostics = append(dep.Diagnostics, resolve.Diagnostic{
+				Kind:    "conflict",
+				Message: leinConflictMessage(accepted, chain),
+			})
+		}
+	}
+	return deps, nil
+}
+
+// parseLeinVector parses one dependency vector such as
+// [org.lwjgl/lwjgl "3.3.1" :classifier "natives-linux" :exclusions [[foo]]].
+func parseLeinVector(content string) (*resolve.Dep, bool) {
+	m := leinVectorRe.FindStringSubmatch(strings.TrimSpace(content))
+	if m == nil {
+		return nil, false
+	}
+	dep := &resolve.Dep{Name: m[1], Version: m[2]}
+	qualifiers := map[string]string{}
+	for _, opt := range leinOptionRe.FindAllStringSubmatch(stripLeinExclusions(m[3]), -1) {
+		switch opt[1] {
+		case "classifier":
+			qualifiers["classifier"] = opt[2]
+		case "extension":
+			qualifiers["type"] = opt[2]
+		case "scope":
+			if opt[2] != "compile" {
+				dep.Scope = opt[2]
+			}
+		}
+	}
+	dep.PURL = resolve.MakePURLWithQualifiers("clojars", dep.Name, dep.Version, qualifiers)
+	return dep, true
+}
 
-		// Calculate depth from leading whitespace
-		depth := 0
-		for _, ch := range line {
-			if ch == ' ' {
-				depth++
-			} else {
-				break
+// stripLeinExclusions removes an ":exclusions [...]" option, whose nested
+// vectors can carry their own :classifier options.
+func stripLeinExclusions(opts string) string {
+	idx := strings.Index(opts, ":exclusions")
+	if idx < 0 {
+		return opts
+	}
+	rest := strings.TrimLeft(opts[idx+len(":exclusions"):], " ")
+	if !strings.HasPrefix(rest, "[") {
+		return opts
+	}
+	depth := 0
+	for i, c := range rest {
+		switch c {
+		case '[':
+			depth++
+		case ']':
+			depth--
+			if depth == 0 {
+				return opts[:idx] + rest[i+1:]
 			}
 		}
-		// Normalize depth: each level is typically 2 spaces
-		depth /= 2
+	}
+	return opts[:idx]
+}
 
-		name := m[1]
-		version := m[2]
+// parseLeinChain parses a conflict path like
+// [ring "1.10.0"] -> [ring/ring-core "1.10.0"] -> [commons-io "2.11.0"].
+func parseLeinChain(line string) []*resolve.Dep {
+	var chain []*resolve.Dep
+	for _, part := range strings.Split(line, " -> ") {
+		dep, ok := parseLeinVector(part)
+		if !ok {
+			return nil
+		}
+		chain = append(chain, dep)
+	}
+	return chain
+}
 
-		treeLines = append(treeLines, resolve.TreeLine{Depth: depth, Content: name + "\t" + version})
+func leinConflictMessage(accepted *resolve.Dep, chain []*resolve.Dep) string {
+	ignored := chain[len(chain)-1]
+	msg := fmt.Sprintf("%s %s overrides %s", accepted.Name, accepted.Version, ignored.Version)
+	if len(chain) > 1 {
+		var path []string
+		for _, dep := range chain[:len(chain)-1] {
+			path = append(path, dep.Name+" "+dep.Version)
+		}
+		msg += " required via " + strings.Join(path, " -> ")
 	}
+	return msg
+}
 
-	return resolve.BuildTree(treeLines, "clojars", resolve.TabContentParser), nil
+// findLeinDep returns the shallowest dependency with the given name and
+// version.
+func findLeinDep(deps []*resolve.Dep, name, version string) *resolve.Dep {
+	for len(deps) > 0 {
+		var next []*resolve.Dep
+		for _, dep := range deps {
+			if dep.Name == name && dep.Version == version {
+				return dep
+			}
+			next = append(next, dep.Deps...)
+		}
+		deps = next
+	}
+	return nil
 }
 
 func init() {
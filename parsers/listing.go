@@ -0,0 +1,59 @@
+package parsers
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Helpers for the `poetry show` and `composer show` text formats, which mix
+// unindented top-level lines, box-drawing trees and column-aligned listings.
+
+// listingCircular is appended to a sub-dependency whose subtree poetry or
+// composer stops expanding because it loops back to an ancestor.
+const listingCircular = "(circular dependency aborted here)"
+
+// listingColumnRe matches the name column of an unindented line, up to where
+// the version starts.
+var listingColumnRe = regexp.MustCompile(`^\S+(\s+)(?:\(!\)\s+)?`)
+
+// isBoxTreeLine reports whether a line is indented or carries box-drawing
+// characters, i.e. it is a sub-dependency rather than a top-level package.
+func isBoxTreeLine(line string) bool {
+	return strings.HasPrefix(line, " ") || strings.HasPrefix(line, "│") ||
+		strings.HasPrefix(line, "├") || strings.HasPrefix(line, "└")
+}
+
+// flatListingLines returns the indices of lines that belong to a flat
+// `poetry show` or `composer show` listing: runs of unindented lines whose
+// version column is aligned by padding. Tree output separates name and
+// version with one space.
+func flatListingLines(lines []string) map[int]bool {
+	listing := make(map[int]bool)
+	start := 0
+	for start < len(lines) {
+		end := start
+		column, padded, aligned := -1, false, true
+		for end < len(lines) && lines[end] != "" && !isBoxTreeLine(lines[end]) {
+			m := listingColumnRe.FindStringSubmatch(lines[end])
+			switch {
+			case m == nil:
+				aligned = false
+			case column == -1:
+				column = len(m[0])
+			case len(m[0]) != column:
+				aligned = false
+			}
+			if m != nil && len(m[1]) > 1 {
+				padded = true
+			}
+			end++
+		}
+		if end-start > 1 && aligned && padded {
+			for i := start; i < end; i++ {
+				listing[i] = true
+			}
+		}
+		start = end + 1
+	}
+	return listing
+}
@@ -1,38 +1,212 @@
 package parsers
 
 import (
+	"fmt"
+	"maps"
 	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/git-pkgs/resolve"
 )
 
-// mixPkgRe matches "name version" or "name ~> constraint (Hex package)" patterns.
-var mixPkgRe = regexp.MustCompile(`^(\S+)\s+(\S+)`)
+// mixDepsRe matches a `mix deps` entry with a loaded version, like
+// "* phoenix 1.7.10 (Hex package) (mix)".
+var mixDepsRe = regexp.MustCompile(`^\* (\S+) ([^\s(]\S*) \(`)
+
+// mixExactVersionRe matches a requirement that pins one version ("1.7.10").
+var mixExactVersionRe = regexp.MustCompile(`^\d+\.\d+\.\d+\S*$`)
+
+// mixLockEntry is one package from mix.lock.
+type mixLockEntry struct {
+	scm      string // "hex" or "git"
+	pkg      string // Hex package name, which can differ from the app name
+	version  string // package version, or the commit for git deps
+	location string // git URL
+	repo     string // Hex repository, like "hexpm" or "hexpm:myorg"
+	deps     []mixLockDep
+}
+
+// mixLockDep is a requirement listed in a Hex package's mix.lock entry.
+type mixLockDep struct {
+	name, constraint string
+	optional         bool
+}
 
 // parseMix parses output from `mix deps.tree`.
+// Lines look like "├── phoenix ~> 1.7.10 (Hex package)": the requirement is
+// kept in Constraint and the parenthesised source marks git ("(https://...)")
+// and path ("(../my_lib)") deps. `mix deps.tree` doesn't show versions, so
+// they are taken from `mix deps` output or mix.lock contents appended after
+// the tree. Without either, requirements that pin one version are used.
 func parseMix(data []byte) ([]*resolve.Dep, error) {
-	lines := strings.Split(string(data), "\n")
+	text := string(data)
+	lock := make(map[string]mixLockEntry)
+	if idx := strings.Index(text, "%{"); idx >= 0 {
+		if terms := parseBeamTerms(text[idx:]); len(terms) > 0 {
+			lock = readMixLock(terms[0])
+		}
+		text = text[:idx]
+	}
+
+	loaded := make(map[string]string)
 	opts := resolve.BoxDrawingOptions()
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if m := mixDepsRe.FindStringSubmatch(line); m != nil {
+			loaded[m[1]] = m[2]
+			continue
+		}
+		if hasTreePrefix(line, opts) {
+			lines = append(lines, line)
+		}
+	}
 	treeLines := resolve.ParseTreeLines(lines, opts)
 
-	return resolve.BuildTree(treeLines, "hex", func(content string) (string, string, bool) {
-		m := mixPkgRe.FindStringSubmatch(content)
-		if m == nil {
-			return "", "", false
+	return resolve.BuildDepTree(treeLines, func(content string) (*resolve.Dep, bool) {
+		content = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(content), "*override*"))
+		name, rest, _ := strings.Cut(content, " ")
+		if name == "" {
+			return nil, false
+		}
+		var source string
+		if strings.HasSuffix(rest, ")") {
+			if idx := strings.LastIndex(rest, "("); idx >= 0 {
+				source = rest[idx+1 : len(rest)-1]
+				rest = rest[:idx]
+			}
+		}
+		constraint := strings.TrimSpace(rest)
+
+		entry := lock[name]
+		version := loaded[name]
+		if version == "" && entry.scm == "hex" {
+			version = entry.version
 		}
-		name := m[1]
-		version := m[2]
-		// Skip constraint-only entries (starting with ~>, >=, etc.)
-		if strings.HasPrefix(version, "~>") || strings.HasPrefix(version, ">=") ||
-			strings.HasPrefix(version, ">") || strings.HasPrefix(version, "<=") {
-			return "", "", false
+		if version == "" && mixExactVersionRe.MatchString(constraint) {
+			version = constraint
 		}
-		// Remove trailing parenthetical like "(Hex package)"
-		return name, version, true
+
+		return newMixDep(name, version, constraint, source, entry), true
 	}), nil
 }
 
+// newMixDep builds a Dep for an app whose `mix deps.tree` source annotation
+// is source: "Hex package", a git URL optionally followed by " - branch", or
+// a local path. Local path deps aren't Hex packages and get no PURL.
+func newMixDep(name, version, constraint, source string, entry mixLockEntry) *resolve.Dep {
+	dep := &resolve.Dep{Name: name, Version: version, Constraint: constraint}
+	switch {
+	case source == "" || source == "Hex package":
+		pkg := entry.pkg
+		if pkg == "" {
+			pkg = name
+		}
+		setHexPackage(dep, pkg, entry.repo)
+	case strings.Contains(source, "://") || strings.HasPrefix(source, "git@"):
+		url, _, _ := strings.Cut(source, " ")
+		ref := ""
+		if entry.scm == "git" {
+			ref = entry.version
+		}
+		setHexGit(dep, url, ref)
+	default:
+		dep.Source = "path"
+		dep.Location = source
+	}
+	return dep
+}
+
+// parseMixLock parses a mix.lock file. The lock doesn't say which packages
+// the project requires directly, so packages no other locked package requires
+// are returned as direct deps, each with the tree of requirements recorded in
+// the lock. Optional requirements that weren't locked are left out. Git deps
+// use their locked commit as the version.
+func parseMixLock(data []byte) ([]*resolve.Dep, error) {
+	terms := parseBeamTerms(string(data))
+	if len(terms) == 0 || terms[0].kind != 'l' {
+		return nil, fmt.Errorf("parsing mix.lock: expected a map")
+	}
+	lock := readMixLock(terms[0])
+
+	names := slices.Sorted(maps.Keys(lock))
+	required := make(map[string]bool)
+	for _, name := range names {
+		for _, req := range lock[name].deps {
+			required[req.name] = true
+		}
+	}
+
+	seen := make(map[string]bool)
+	var buildDep func(name, constraint string) *resolve.Dep
+	buildDep = func(name, constraint string) *resolve.Dep {
+		entry := lock[name]
+		source := ""
+		if entry.scm == "git" {
+			source = entry.location
+		}
+		dep := newMixDep(name, entry.version, constraint, source, entry)
+		dep.Deps = []*resolve.Dep{}
+		if seen[name] {
+			dep.Deduped = len(entry.deps) > 0
+			return dep
+		}
+		seen[name] = true
+		for _, req := range entry.deps {
+			if _, ok := lock[req.name]; !ok && req.optional {
+				continue
+			}
+			dep.Deps = append(dep.Deps, buildDep(req.name, req.constraint))
+		}
+		return dep
+	}
+
+	deps := []*resolve.Dep{}
+	for _, name := range names {
+		if !required[name] {
+			deps = append(deps, buildDep(name, ""))
+		}
+	}
+	return deps, nil
+}
+
+// readMixLock reads the map in a mix.lock file. Hex entries look like
+// {:hex, :jason, "1.4.1", "hash", [:mix], [deps], "hexpm", "hash"}, with
+// "hexpm:myorg" as the repository for private organisation packages, and git
+// entries like {:git, "https://...", "commit", [branch: "main"]}.
+func readMixLock(term beamTerm) map[string]mixLockEntry {
+	lock := make(map[string]mixLockEntry)
+	for _, pair := range term.items {
+		name, value := pair.item(0).str(), pair.item(1)
+		if name == "" || value.kind != 't' {
+			continue
+		}
+		entry := mixLockEntry{scm: value.item(0).str()}
+		switch entry.scm {
+		case "hex":
+			entry.pkg = value.item(1).str()
+			entry.version = value.item(2).str()
+			entry.repo = value.item(6).str()
+			// {:jason, "~> 1.0", [hex: :jason, repo: "hexpm", optional: true]}
+			for _, req := range value.item(5).items {
+				opts := req.item(2)
+				optional, _ := opts.keyword("optional")
+				entry.deps = append(entry.deps, mixLockDep{
+					name:       req.item(0).str(),
+					constraint: req.item(1).str(),
+					optional:   optional.str() == "true",
+				})
+			}
+		case "git":
+			entry.location = value.item(1).str()
+			entry.version = value.item(2).str()
+		}
+		lock[name] = entry
+	}
+	return lock
+}
+
 func init() {
 	resolve.Register("mix", "hex", parseMix)
+	resolve.Register("mix-lock", "hex", parseMixLock)
 }
@@ -1,17 +1,28 @@
 package parsers
 
 import (
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/git-pkgs/resolve"
 )
 
 // npmPackage represents a package in npm/pnpm JSON output.
 type npmPackage struct {
-	Version         string                `json:"version"`
-	Dependencies    map[string]npmPackage `json:"dependencies"`
-	DevDependencies npmDepMap             `json:"devDependencies"`
+	Name                 string                `json:"name"`
+	Version              string                `json:"version"`
+	Path                 string                `json:"path"`
+	Resolved             string                `json:"resolved"`
+	Integrity            string                `json:"integrity"`
+	Dependencies         map[string]npmPackage `json:"dependencies"`
+	DevDependencies      npmDepMap             `json:"devDependencies"`
+	OptionalDependencies npmDepMap             `json:"optionalDependencies"`
 }
 
 // npmDepMap is a map that tolerates values being either package objects or
@@ -30,47 +41,294 @@ func (d *npmDepMap) UnmarshalJSON(data []byte) error {
 	return nil
 }
 
-// parseNPM parses output from `npm ls --depth Infinity --json --long`.
+// npmWorkspaces indexes workspace packages by path and name so that links
+// between them can be recognized.
+type npmWorkspaces struct {
+	byPath map[string]npmPackage
+	byName map[string]npmPackage
+}
+
+func newNPMWorkspaces(pkgs []npmPackage) npmWorkspaces {
+	ws := npmWorkspaces{byPath: make(map[string]npmPackage), byName: make(map[string]npmPackage)}
+	for _, pkg := range pkgs {
+		if pkg.Path != "" {
+			ws.byPath[filepath.Clean(pkg.Path)] = pkg
+		}
+		if pkg.Name != "" {
+			ws.byName[pkg.Name] = pkg
+		}
+	}
+	return ws
+}
+
+// parseNPM parses output from `npm ls --depth Infinity --json --long`
+// (or `npm ls --all --json --long`).
+// The --long fields resolved, integrity and path identify git, tarball and
+// local packages, which get vcs_url or download_url qualifiers, and packages
+// from a registry other than npmjs.org get repository_url. In a workspace
+// project, npm lists each workspace as a file: dependency of the root inside
+// the project directory; the root and each workspace are then returned as
+// separate roots. Workspace packages and file: dependencies get no PURL.
 func parseNPM(data []byte) ([]*resolve.Dep, error) {
 	var root npmPackage
 	if err := json.Unmarshal(data, &root); err != nil {
 		return nil, fmt.Errorf("parsing npm output: %w", err)
 	}
-	return walkNPMDeps(root.Dependencies, "npm"), nil
+
+	var members []npmPackage
+	rootDeps := make(map[string]npmPackage)
+	for name, pkg := range root.Dependencies {
+		location, ok := npmFileLocation(pkg, root.Path)
+		if ok && root.Path != "" && isWithinDir(root.Path, location) {
+			pkg.Name = name
+			pkg.Path = location
+			members = append(members, pkg)
+			continue
+		}
+		rootDeps[name] = pkg
+	}
+
+	if len(members) == 0 {
+		return walkNPMDeps(root.Dependencies, root.Path, npmWorkspaces{}, ""), nil
+	}
+
+	sort.Slice(members, func(i, j int) bool { return members[i].Name < members[j].Name })
+	ws := newNPMWorkspaces(members)
+	roots := []*resolve.Dep{{
+		Name:     root.Name,
+		Version:  root.Version,
+		Source:   "workspace",
+		Location: root.Path,
+		Deps:     walkNPMDeps(rootDeps, root.Path, ws, ""),
+	}}
+	for _, member := range members {
+		deps := walkNPMDeps(member.Dependencies, root.Path, ws, "")
+		if deps == nil {
+			deps = []*resolve.Dep{}
+		}
+		roots = append(roots, &resolve.Dep{
+			Name:     member.Name,
+			Version:  member.Version,
+			Source:   "workspace",
+			Location: member.Path,
+			Deps:     deps,
+		})
+	}
+	return roots, nil
 }
 
 // parsePNPM parses output from `pnpm list --json --depth Infinity`.
-// PNPM returns a JSON array of workspace entries.
+// PNPM returns a JSON array of workspace entries. A single entry is the
+// project itself and its dependencies are returned directly. With several
+// entries each workspace package becomes a root holding its own dependencies,
+// and link: or workspace: versions pointing at another entry are recorded as
+// workspace edges rather than registry packages. Workspace packages and
+// link: paths get no PURL.
 func parsePNPM(data []byte) ([]*resolve.Dep, error) {
 	var entries []npmPackage
 	if err := json.Unmarshal(data, &entries); err != nil {
 		return nil, fmt.Errorf("parsing pnpm output: %w", err)
 	}
-	var deps []*resolve.Dep
+
+	ws := newNPMWorkspaces(entries)
+	if len(entries) == 1 {
+		return pnpmEntryDeps(entries[0], ws), nil
+	}
+
+	var roots []*resolve.Dep
 	for _, entry := range entries {
-		deps = append(deps, walkNPMDeps(entry.Dependencies, "npm")...)
-		deps = append(deps, walkNPMDeps(entry.DevDependencies, "npm")...)
+		roots = append(roots, &resolve.Dep{
+			Name:     entry.Name,
+			Version:  entry.Version,
+			Source:   "workspace",
+			Location: entry.Path,
+			Deps:     pnpmEntryDeps(entry, ws),
+		})
+	}
+	return roots, nil
+}
+
+func pnpmEntryDeps(entry npmPackage, ws npmWorkspaces) []*resolve.Dep {
+	deps := walkNPMDeps(entry.Dependencies, entry.Path, ws, "")
+	deps = append(deps, walkNPMDeps(entry.DevDependencies, entry.Path, ws, "dev")...)
+	deps = append(deps, walkNPMDeps(entry.OptionalDependencies, entry.Path, ws, "optional")...)
+	if deps == nil {
+		deps = []*resolve.Dep{}
 	}
-	return deps, nil
+	return deps
 }
 
-func walkNPMDeps(deps map[string]npmPackage, ecosystem string) []*resolve.Dep {
+// walkNPMDeps converts a dependency map to Deps. dir is the directory that
+// relative link: and file: locations are resolved against.
+func walkNPMDeps(deps map[string]npmPackage, dir string, ws npmWorkspaces, scope string) []*resolve.Dep {
 	var result []*resolve.Dep
 	for name, pkg := range deps {
 		dep := &resolve.Dep{
-			PURL:    resolve.MakePURL(ecosystem, name, pkg.Version),
 			Name:    name,
 			Version: pkg.Version,
+			Scope:   scope,
 			Deps:    []*resolve.Dep{},
 		}
-		if len(pkg.Dependencies) > 0 {
-			dep.Deps = walkNPMDeps(pkg.Dependencies, ecosystem)
+		qualifiers, linked := resolveNPMSource(dep, pkg, dir, ws)
+		if !linked && len(pkg.Dependencies) > 0 {
+			childDir := dir
+			if pkg.Path != "" && strings.HasPrefix(pkg.Version, "link:") {
+				childDir = pkg.Path
+			}
+			dep.Deps = walkNPMDeps(pkg.Dependencies, childDir, ws, "")
+		}
+		// Workspace and local path packages aren't in the registry
+		if dep.Source != "workspace" && dep.Source != "path" {
+			dep.PURL = resolve.MakePURLWithQualifiers("npm", name, dep.Version, qualifiers)
 		}
 		result = append(result, dep)
 	}
 	return result
 }
 
+// resolveNPMSource sets Source and Location for packages that don't come from
+// the registry and returns the PURL qualifiers that identify them. It reports
+// whether the package is a link to a workspace member, whose subtree is listed
+// under the member's own root.
+func resolveNPMSource(dep *resolve.Dep, pkg npmPackage, dir string, ws npmWorkspaces) (map[string]string, bool) {
+	if strings.HasPrefix(pkg.Version, "link:") || strings.HasPrefix(pkg.Version, "workspace:") {
+		return nil, resolveNPMLink(dep, pkg, dir, ws)
+	}
+
+	resolved := pkg.Resolved
+	switch {
+	case resolved == "":
+		return nil, false
+	case strings.HasPrefix(resolved, "file:"):
+		location, _ := npmFileLocation(pkg, dir)
+		dep.Location = location
+		if member, ok := ws.byPath[filepath.Clean(location)]; ok {
+			dep.Source = "workspace"
+			dep.Version = member.Version
+			dep.Deduped = true
+			return nil, true
+		}
+		dep.Source = "path"
+		return nil, false
+	case isNPMGitSpec(resolved):
+		dep.Source = "git"
+		dep.Location = resolved
+		return map[string]string{"vcs_url": npmVCSURL(resolved)}, false
+	}
+
+	u, err := url.Parse(resolved)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+		return nil, false
+	}
+
+	// Registry tarballs look like <registry>/<name>/-/<basename>-<version>.tgz
+	if base, _, ok := strings.Cut(resolved, "/"+dep.Name+"/-/"); ok {
+		if u.Host == "registry.npmjs.org" {
+			return nil, false
+		}
+		return map[string]string{"repository_url": base}, false
+	}
+
+	dep.Source = "url"
+	dep.Location = resolved
+	return map[string]string{
+		"download_url": resolved,
+		"checksum":     npmChecksum(pkg.Integrity),
+	}, false
+}
+
+// resolveNPMLink handles pnpm's link: and workspace: versions. Links to another
+// workspace package take that package's version and are marked as workspace
+// edges whose subtree is listed under the package's own root; other links
+// are local paths with no known version. It reports whether the link is to a
+// workspace package.
+func resolveNPMLink(dep *resolve.Dep, pkg npmPackage, dir string, ws npmWorkspaces) bool {
+	location := pkg.Path
+	if target, ok := strings.CutPrefix(pkg.Version, "link:"); ok && location == "" {
+		location = target
+		if !filepath.IsAbs(target) && dir != "" {
+			location = filepath.Join(dir, target)
+		}
+	}
+
+	member, ok := ws.byPath[filepath.Clean(location)]
+	if !ok || location == "" {
+		member, ok = ws.byName[dep.Name]
+	}
+	dep.Location = location
+	if !ok {
+		dep.Version = ""
+		dep.Source = "path"
+		return false
+	}
+	dep.Version = member.Version
+	dep.Source = "workspace"
+	dep.Location = member.Path
+	dep.Deduped = true
+	return true
+}
+
+// npmFileLocation returns the absolute location of a file: dependency,
+// preferring the path npm reports over resolving the spec against dir.
+func npmFileLocation(pkg npmPackage, dir string) (string, bool) {
+	spec, ok := strings.CutPrefix(pkg.Resolved, "file:")
+	if !ok {
+		return "", false
+	}
+	if pkg.Path != "" && !strings.Contains(filepath.ToSlash(pkg.Path), "/node_modules/") {
+		return filepath.Clean(pkg.Path), true
+	}
+	if filepath.IsAbs(spec) || dir == "" {
+		return filepath.Clean(spec), true
+	}
+	return filepath.Join(dir, spec), true
+}
+
+func isWithinDir(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// npmGitPrefixes are resolved-URL prefixes for packages installed from git.
+var npmGitPrefixes = []string{"git+", "git:", "github:", "gitlab:", "bitbucket:"}
+
+func isNPMGitSpec(resolved string) bool {
+	for _, prefix := range npmGitPrefixes {
+		if strings.HasPrefix(resolved, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// npmVCSURL converts npm's "git+ssh://host/repo.git#commit" form to the
+// purl-spec vcs_url form, which puts the revision after "@".
+func npmVCSURL(resolved string) string {
+	if repo, rev, ok := strings.Cut(resolved, "#"); ok {
+		return repo + "@" + rev
+	}
+	return resolved
+}
+
+// npmChecksum converts a subresource integrity string ("sha512-<base64>")
+// to the purl-spec checksum form ("sha512:<hex>"). It returns "" when there is
+// no integrity, as for pnpm, which never reports it.
+func npmChecksum(integrity string) string {
+	fields := strings.Fields(integrity)
+	if len(fields) == 0 {
+		return ""
+	}
+	algo, digest, ok := strings.Cut(fields[0], "-")
+	if !ok {
+		return ""
+	}
+	raw, err := base64.StdEncoding.DecodeString(digest)
+	if err != nil {
+		return ""
+	}
+	return algo + ":" + hex.EncodeToString(raw)
+}
+
 func init() {
 	resolve.Register("npm", "npm", parseNPM)
 	resolve.Register("pnpm", "npm", parsePNPM)
@@ -3,40 +3,311 @@ package parsers
 import (
 	"bufio"
 	"bytes"
+	"encoding/json"
+	"fmt"
+	"maps"
 	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/git-pkgs/resolve"
 )
 
-// nugetPkgRe matches "> PackageName  version" or "> PackageName  (requested) resolved".
-var nugetPkgRe = regexp.MustCompile(`>\s+(\S+)\s+(?:\([^)]+\)\s+)?(\S+)`)
+// nugetProjectRe matches "Project 'MyProject' has the following package references".
+var nugetProjectRe = regexp.MustCompile(`^Project '([^']+)'`)
 
-// parseNuget parses output from `dotnet list package --include-transitive`.
+// nugetFrameworkRe matches the "[net8.0]:" line that starts a framework section.
+var nugetFrameworkRe = regexp.MustCompile(`^\[([^\]]+)\]:?$`)
+
+// nugetFramework is the package list for one target framework of one project.
+type nugetFramework struct {
+	project, path, framework string
+	packages                 []nugetPackage
+}
+
+type nugetPackage struct {
+	id, requested, resolved string
+	transitive              bool
+}
+
+// parseNuget parses output from `dotnet list package --include-transitive`,
+// in either the default table format or with `--format json`.
+// With a single project and target framework the packages are returned
+// directly. Otherwise each project/framework pair becomes a root whose Deps
+// are that framework's packages; projects aren't NuGet packages, so roots
+// have no PURL. Transitive packages are marked Indirect; neither format
+// reports which package pulls them in.
 func parseNuget(data []byte) ([]*resolve.Dep, error) {
-	var deps []*resolve.Dep
-	scanner := bufio.NewScanner(bytes.NewReader(data))
+	var frameworks []nugetFramework
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		var err error
+		if frameworks, err = parseNugetJSON(trimmed); err != nil {
+			return nil, err
+		}
+	} else {
+		frameworks = parseNugetTable(data)
+	}
+
+	if len(frameworks) == 1 {
+		return nugetPackageDeps(frameworks[0]), nil
+	}
+	var roots []*resolve.Dep
+	for _, fw := range frameworks {
+		roots = append(roots, &resolve.Dep{
+			Name:     fw.project,
+			Target:   fw.framework,
+			Source:   "project",
+			Location: fw.path,
+			Deps:     nugetPackageDeps(fw),
+		})
+	}
+	return roots, nil
+}
+
+func nugetPackageDeps(fw nugetFramework) []*resolve.Dep {
+	deps := []*resolve.Dep{}
+	for _, pkg := range fw.packages {
+		deps = append(deps, &resolve.Dep{
+			PURL:       resolve.MakePURL("nuget", pkg.id, pkg.resolved),
+			Name:       pkg.id,
+			Version:    pkg.resolved,
+			Constraint: pkg.requested,
+			Target:     fw.framework,
+			Indirect:   pkg.transitive,
+		})
+	}
+	return deps
+}
+
+// parseNugetJSON reads the `dotnet list package --format json` document
+// produced by .NET SDK 7.0.200 and later.
+func parseNugetJSON(data []byte) ([]nugetFramework, error) {
+	type jsonPackage struct {
+		ID               string `json:"id"`
+		RequestedVersion string `json:"requestedVersion"`
+		ResolvedVersion  string `json:"resolvedVersion"`
+	}
+	var output struct {
+		Projects []struct {
+			Path       string `json:"path"`
+			Frameworks []struct {
+				Framework          string        `json:"framework"`
+				TopLevelPackages   []jsonPackage `json:"topLevelPackages"`
+				TransitivePackages []jsonPackage `json:"transitivePackages"`
+			} `json:"frameworks"`
+		} `json:"projects"`
+	}
+	if err := json.Unmarshal(data, &output); err != nil {
+		return nil, fmt.Errorf("parsing dotnet list package output: %w", err)
+	}
+
+	var frameworks []nugetFramework
+	for _, project := range output.Projects {
+		for _, f := range project.Frameworks {
+			fw := nugetFramework{project: nugetProjectName(project.Path), path: project.Path, framework: f.Framework}
+			for _, pkg := range f.TopLevelPackages {
+				fw.packages = append(fw.packages, nugetPackage{id: pkg.ID, requested: pkg.RequestedVersion, resolved: pkg.ResolvedVersion})
+			}
+			for _, pkg := range f.TransitivePackages {
+				fw.packages = append(fw.packages, nugetPackage{id: pkg.ID, resolved: pkg.ResolvedVersion, transitive: true})
+			}
+			frameworks = append(frameworks, fw)
+		}
+	}
+	return frameworks, nil
+}
 
+// parseNugetTable reads the default table output. Each project has one
+// section per framework, split into "Top-level Package" and "Transitive
+// Package" tables. Rows look like "> Name  (A)  [1.0.0, )  1.0.0  2.0.0", where
+// "(A)" marks auto-referenced packages and the Latest column only appears
+// with --outdated.
+func parseNugetTable(data []byte) []nugetFramework {
+	var frameworks []nugetFramework
+	var project string
+	current := -1
+	transitive, hasLatest := false, false
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
 	for scanner.Scan() {
 		line := strings.TrimSpace(scanner.Text())
+		if m := nugetProjectRe.FindStringSubmatch(line); m != nil {
+			project = m[1]
+			current = -1
+			continue
+		}
+		if m := nugetFrameworkRe.FindStringSubmatch(line); m != nil {
+			frameworks = append(frameworks, nugetFramework{project: project, framework: m[1]})
+			current = len(frameworks) - 1
+			continue
+		}
+		if strings.HasPrefix(line, "Top-level Package") || strings.HasPrefix(line, "Transitive Package") {
+			transitive = strings.HasPrefix(line, "Transitive")
+			hasLatest = strings.Contains(line, "Latest")
+			continue
+		}
 		if !strings.HasPrefix(line, ">") {
 			continue
 		}
-		m := nugetPkgRe.FindStringSubmatch(line)
-		if m == nil {
+		if current < 0 {
+			frameworks = append(frameworks, nugetFramework{project: project})
+			current = len(frameworks) - 1
+		}
+
+		fields := strings.Fields(line)[1:]
+		if len(fields) < 2 {
 			continue
 		}
-		name := m[1]
-		version := m[2]
-		deps = append(deps, &resolve.Dep{
-			PURL:    resolve.MakePURL("nuget", name, version),
-			Name:    name,
-			Version: version,
+		pkg := nugetPackage{id: fields[0], transitive: transitive}
+		var values []string
+		for _, f := range fields[1:] {
+			if f != "(A)" && f != "(D)" {
+				values = append(values, f)
+			}
+		}
+		if hasLatest && len(values) > 1 {
+			values = values[:len(values)-1]
+		}
+		if len(values) == 0 {
+			continue
+		}
+		pkg.resolved = values[len(values)-1]
+		if !transitive {
+			pkg.requested = strings.Join(values[:len(values)-1], " ")
+		}
+		frameworks[current].packages = append(frameworks[current].packages, pkg)
+	}
+	return frameworks
+}
+
+// nugetProjectName returns "MyApp" for "/src/MyApp/MyApp.csproj" or
+// "C:\src\MyApp\MyApp.csproj".
+func nugetProjectName(path string) string {
+	name := path[strings.LastIndexAny(path, `/\`)+1:]
+	if idx := strings.LastIndex(name, "."); idx > 0 {
+		name = name[:idx]
+	}
+	return name
+}
+
+// parseNugetAssets parses the obj/project.assets.json file written by
+// `dotnet restore`. Each target framework in "targets" holds the full
+// restore graph as "Name/Version" entries with their dependency ranges;
+// direct deps come from "projectFileDependencyGroups" plus referenced
+// projects. Runtime-specific graphs ("net8.0/linux-x64") are skipped. With
+// more than one framework each gets its own root, as in parseNuget. Project
+// references and roots are marked as projects and get no PURL.
+func parseNugetAssets(data []byte) ([]*resolve.Dep, error) {
+	type assetsEntry struct {
+		Type         string            `json:"type"`
+		Dependencies map[string]string `json:"dependencies"`
+	}
+	var assets struct {
+		Targets   map[string]map[string]assetsEntry `json:"targets"`
+		Libraries map[string]struct {
+			Type string `json:"type"`
+			Path string `json:"path"`
+		} `json:"libraries"`
+		ProjectFileDependencyGroups map[string][]string `json:"projectFileDependencyGroups"`
+		Project                     struct {
+			Restore struct {
+				ProjectName string `json:"projectName"`
+				ProjectPath string `json:"projectPath"`
+			} `json:"restore"`
+		} `json:"project"`
+	}
+	if err := json.Unmarshal(data, &assets); err != nil {
+		return nil, fmt.Errorf("parsing project.assets.json: %w", err)
+	}
+
+	var frameworks []string
+	for tfm := range assets.Targets {
+		if !strings.Contains(tfm, "/") {
+			frameworks = append(frameworks, tfm)
+		}
+	}
+	slices.Sort(frameworks)
+
+	var roots []*resolve.Dep
+	for _, tfm := range frameworks {
+		target := assets.Targets[tfm]
+		byName := make(map[string]string, len(target))
+		required := make(map[string]bool)
+		for key, entry := range target {
+			name, _, _ := strings.Cut(key, "/")
+			byName[strings.ToLower(name)] = key
+			for dep := range entry.Dependencies {
+				required[strings.ToLower(dep)] = true
+			}
+		}
+
+		seen := make(map[string]bool)
+		var buildDep func(name, constraint string) *resolve.Dep
+		buildDep = func(name, constraint string) *resolve.Dep {
+			key, ok := byName[strings.ToLower(name)]
+			if !ok {
+				return &resolve.Dep{
+					PURL:       resolve.MakePURL("nuget", name, ""),
+					Name:       name,
+					Constraint: constraint,
+					Target:     tfm,
+					Deps:       []*resolve.Dep{},
+				}
+			}
+			id, version, _ := strings.Cut(key, "/")
+			entry := target[key]
+			dep := &resolve.Dep{
+				Name:       id,
+				Version:    version,
+				Constraint: constraint,
+				Target:     tfm,
+				Deps:       []*resolve.Dep{},
+			}
+			if entry.Type == "project" {
+				dep.Source = "project"
+				dep.Location = assets.Libraries[key].Path
+			} else {
+				dep.PURL = resolve.MakePURL("nuget", id, version)
+			}
+			if seen[key] {
+				dep.Deduped = len(entry.Dependencies) > 0
+				return dep
+			}
+			seen[key] = true
+			for _, child := range slices.Sorted(maps.Keys(entry.Dependencies)) {
+				dep.Deps = append(dep.Deps, buildDep(child, entry.Dependencies[child]))
+			}
+			return dep
+		}
+
+		deps := []*resolve.Dep{}
+		for _, spec := range assets.ProjectFileDependencyGroups[tfm] {
+			name, constraint, _ := strings.Cut(spec, " ")
+			deps = append(deps, buildDep(name, strings.TrimSpace(constraint)))
+		}
+		// Project references aren't listed in projectFileDependencyGroups
+		for _, key := range slices.Sorted(maps.Keys(target)) {
+			name, _, _ := strings.Cut(key, "/")
+			if target[key].Type == "project" && !required[strings.ToLower(name)] {
+				deps = append(deps, buildDep(name, ""))
+			}
+		}
+
+		if len(frameworks) == 1 {
+			return deps, nil
+		}
+		roots = append(roots, &resolve.Dep{
+			Name:     assets.Project.Restore.ProjectName,
+			Target:   tfm,
+			Source:   "project",
+			Location: assets.Project.Restore.ProjectPath,
+			Deps:     deps,
 		})
 	}
-	return deps, nil
+	return roots, nil
 }
 
 func init() {
 	resolve.Register("nuget", "nuget", parseNuget)
+	resolve.Register("nuget-assets", "nuget", parseNugetAssets)
 }
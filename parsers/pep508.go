@@ -0,0 +1,283 @@
+package parsers
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/git-pkgs/resolve"
+)
+
+// pythonReqRe matches the name and optional extras at the start of a PEP 508
+// requirement like "requests[socks]>=2.0; python_version >= '3.8'".
+var pythonReqRe = regexp.MustCompile(`^\s*([A-Za-z0-9][A-Za-z0-9._-]*)\s*(?:\[([^\]]*)\])?`)
+
+// pythonRequirement is a parsed PEP 508 requirement string.
+type pythonRequirement struct {
+	Name   string
+	Extras []string
+	Marker string
+}
+
+// parsePythonRequirement splits a PEP 508 requirement into name, extras and marker.
+func parsePythonRequirement(s string) (pythonRequirement, bool) {
+	spec, marker, _ := strings.Cut(s, ";")
+	m := pythonReqRe.FindStringSubmatch(spec)
+	if m == nil {
+		return pythonRequirement{}, false
+	}
+	req := pythonRequirement{Name: m[1], Marker: strings.TrimSpace(marker)}
+	for _, extra := range strings.Split(m[2], ",") {
+		if extra = strings.TrimSpace(extra); extra != "" {
+			req.Extras = append(req.Extras, extra)
+		}
+	}
+	return req, true
+}
+
+// evalPythonMarker reports whether a PEP 508 environment marker holds for the
+// given environment and set of requested extras. Variables missing from env
+// cannot be decided and compare as true, so edges are kept rather than dropped.
+// Malformed markers also evaluate to true.
+func evalPythonMarker(marker string, env map[string]string, extras []string) bool {
+	if strings.TrimSpace(marker) == "" {
+		return true
+	}
+	p := &markerParser{tokens: tokenizeMarker(marker), env: env, extras: extras}
+	result, ok := p.parseOr()
+	if !ok || p.pos != len(p.tokens) {
+		return true
+	}
+	return result
+}
+
+// markerVersionVars are marker variables compared as versions rather than strings.
+var markerVersionVars = map[string]bool{
+	"python_version":         true,
+	"python_full_version":    true,
+	"implementation_version": true,
+}
+
+type markerParser struct {
+	tokens []string
+	pos    int
+	env    map[string]string
+	extras []string
+}
+
+func (p *markerParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *markerParser) next() string {
+	tok := p.peek()
+	p.pos++
+	return tok
+}
+
+func (p *markerParser) parseOr() (bool, bool) {
+	result, ok := p.parseAnd()
+	for ok && p.peek() == "or" {
+		p.next()
+		var rhs bool
+		rhs, ok = p.parseAnd()
+		result = result || rhs
+	}
+	return result, ok
+}
+
+func (p *markerParser) parseAnd() (bool, bool) {
+	result, ok := p.parseExpr()
+	for ok && p.peek() == "and" {
+		p.next()
+		var rhs bool
+		rhs, ok = p.parseExpr()
+		result = result && rhs
+	}
+	return result, ok
+}
+
+func (p *markerParser) parseExpr() (bool, bool) {
+	if p.peek() == "(" {
+		p.next()
+		result, ok := p.parseOr()
+		if !ok || p.next() != ")" {
+			return false, false
+		}
+		return result, true
+	}
+
+	lhs := p.next()
+	op := p.next()
+	if op == "not" && p.peek() == "in" {
+		p.next()
+		op = "not in"
+	}
+	rhs := p.next()
+	if lhs == "" || op == "" || rhs == "" {
+		return false, false
+	}
+	return p.compare(lhs, op, rhs), true
+}
+
+// compare evaluates a single "lhs op rhs" marker comparison.
+func (p *markerParser) compare(lhs, op, rhs string) bool {
+	if lhs == "extra" || rhs == "extra" {
+		other := rhs
+		if rhs == "extra" {
+			other = lhs
+		}
+		want := resolve.NormalizePyPIName(unquoteMarker(other))
+		found := false
+		for _, extra := range p.extras {
+			if resolve.NormalizePyPIName(extra) == want {
+				found = true
+				break
+			}
+		}
+		if op == "!=" {
+			return !found
+		}
+		return found
+	}
+
+	versionCompare := markerVersionVars[lhs] || markerVersionVars[rhs]
+	left, lok := p.value(lhs)
+	right, rok := p.value(rhs)
+	if !lok || !rok {
+		return true
+	}
+
+	switch op {
+	case "in":
+		return strings.Contains(right, left)
+	case "not in":
+		return !strings.Contains(right, left)
+	case "===":
+		return left == right
+	}
+
+	if !versionCompare {
+		switch op {
+		case "==":
+			return left == right
+		case "!=":
+			return left != right
+		}
+	}
+
+	cmp := comparePythonVersions(left, right)
+	switch op {
+	case "==":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "~=":
+		prefix := right
+		if idx := strings.LastIndex(right, "."); idx > 0 {
+			prefix = right[:idx]
+		}
+		return cmp >= 0 && (left == prefix || strings.HasPrefix(left, prefix+"."))
+	}
+	return true
+}
+
+// value resolves a marker operand to a string. Quoted literals are returned
+// unquoted; variables are looked up in the environment.
+func (p *markerParser) value(tok string) (string, bool) {
+	if strings.HasPrefix(tok, `"`) || strings.HasPrefix(tok, "'") {
+		return unquoteMarker(tok), true
+	}
+	if p.env == nil {
+		return "", false
+	}
+	v, ok := p.env[tok]
+	return v, ok
+}
+
+func unquoteMarker(tok string) string {
+	if len(tok) >= 2 && (tok[0] == '"' || tok[0] == '\'') && tok[len(tok)-1] == tok[0] {
+		return tok[1 : len(tok)-1]
+	}
+	return tok
+}
+
+// tokenizeMarker splits a marker expression into quoted strings, operators,
+// parentheses and bare words.
+func tokenizeMarker(s string) []string {
+	var tokens []string
+	for i := 0; i < len(s); {
+		c := s[i]
+		switch {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '(' || c == ')':
+			tokens = append(tokens, string(c))
+			i++
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(s[i+1:], c)
+			if end < 0 {
+				tokens = append(tokens, s[i:])
+				return tokens
+			}
+			tokens = append(tokens, s[i:i+end+2])
+			i += end + 2
+		case strings.ContainsRune("<>=!~", rune(c)):
+			j := i
+			for j < len(s) && strings.ContainsRune("<>=!~", rune(s[j])) {
+				j++
+			}
+			tokens = append(tokens, s[i:j])
+			i = j
+		default:
+			j := i
+			for j < len(s) && !strings.ContainsRune(" \t()<>=!~\"'", rune(s[j])) {
+				j++
+			}
+			tokens = append(tokens, s[i:j])
+			i = j
+		}
+	}
+	return tokens
+}
+
+// comparePythonVersions compares the numeric release segments of two versions,
+// returning -1, 0 or 1. Non-numeric segments compare as strings.
+func comparePythonVersions(a, b string) int {
+	as := strings.Split(a, ".")
+	bs := strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		x, y := "0", "0"
+		if i < len(as) {
+			x = as[i]
+		}
+		if i < len(bs) {
+			y = bs[i]
+		}
+		xn, xerr := strconv.Atoi(x)
+		yn, yerr := strconv.Atoi(y)
+		switch {
+		case xerr == nil && yerr == nil:
+			if xn != yn {
+				if xn < yn {
+					return -1
+				}
+				return 1
+			}
+		case x != y:
+			return strings.Compare(x, y)
+		}
+	}
+	return 0
+}
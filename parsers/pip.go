@@ -3,93 +3,177 @@ package parsers
 import (
 	"encoding/json"
 	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/git-pkgs/resolve"
 )
 
+// pipDist is an installed distribution in `pip inspect` output.
+type pipDist struct {
+	Metadata struct {
+		Name         string   `json:"name"`
+		Version      string   `json:"version"`
+		RequiresDist []string `json:"requires_dist"`
+	} `json:"metadata"`
+	Requested *bool `json:"requested"`
+	DirectURL *struct {
+		URL     string `json:"url"`
+		VCSInfo *struct {
+			VCS      string `json:"vcs"`
+			CommitID string `json:"commit_id"`
+		} `json:"vcs_info"`
+	} `json:"direct_url"`
+}
+
 // parsePip parses output from `pip inspect`.
-// Format: {"installed": [{"metadata": {"name": "...", "version": "..."}}, ...]}
+// Format: {"installed": [{"metadata": {...}, "requested": true}, ...], "environment": {...}}
+// The tree is built by matching each distribution's requires_dist entries against
+// installed packages, evaluating environment markers against the reported
+// environment. Distributions with requested: true are the direct deps; if no
+// distribution reports that field, packages nothing else requires are used,
+// followed by any packages only reachable through a cycle.
+// A package is expanded again when a later requirement asks for extras it
+// wasn't expanded with. Distributions installed from a direct_url get Source
+// "git" (or the other VCS) with a vcs_url qualifier, "url" with a
+// download_url, or "path" for local directories and files, which get no PURL.
 func parsePip(data []byte) ([]*resolve.Dep, error) {
 	var output struct {
-		Installed []struct {
-			Metadata struct {
-				Name    string `json:"name"`
-				Version string `json:"version"`
-			} `json:"metadata"`
-		} `json:"installed"`
+		Installed   []pipDist         `json:"installed"`
+		Environment map[string]string `json:"environment"`
 	}
 	if err := json.Unmarshal(data, &output); err != nil {
 		return nil, fmt.Errorf("parsing pip output: %w", err)
 	}
 
-	var deps []*resolve.Dep
-	for _, pkg := range output.Installed {
-		name := pkg.Metadata.Name
-		version := pkg.Metadata.Version
-		if name == "" {
+	installed := make(map[string]*pipDist)
+	var order []string
+	hasRequested := false
+	for i := range output.Installed {
+		dist := &output.Installed[i]
+		if dist.Metadata.Name == "" {
 			continue
 		}
-		deps = append(deps, &resolve.Dep{
-			PURL:    resolve.MakePURL("pypi", name, version),
-			Name:    name,
-			Version: version,
-		})
+		key := resolve.NormalizePyPIName(dist.Metadata.Name)
+		if _, ok := installed[key]; !ok {
+			order = append(order, key)
+		}
+		installed[key] = dist
+		if dist.Requested != nil {
+			hasRequested = true
+		}
 	}
-	return deps, nil
-}
 
-// parseConda parses output from `conda list --json`.
-// Format: [{"name": "...", "version": "..."}, ...]
-func parseConda(data []byte) ([]*resolve.Dep, error) {
-	var packages []struct {
-		Name    string `json:"name"`
-		Version string `json:"version"`
+	type edge struct {
+		name   string
+		extras []string
 	}
-	if err := json.Unmarshal(data, &packages); err != nil {
-		return nil, fmt.Errorf("parsing conda output: %w", err)
+	requires := func(key string, extras []string) []edge {
+		var edges []edge
+		for _, spec := range installed[key].Metadata.RequiresDist {
+			req, ok := parsePythonRequirement(spec)
+			if !ok {
+				continue
+			}
+			child := resolve.NormalizePyPIName(req.Name)
+			if _, ok := installed[child]; !ok || child == key {
+				continue
+			}
+			if !evalPythonMarker(req.Marker, output.Environment, extras) {
+				continue
+			}
+			edges = append(edges, edge{name: child, extras: req.Extras})
+		}
+		return edges
 	}
 
-	var deps []*resolve.Dep
-	for _, pkg := range packages {
-		if pkg.Name == "" {
-			continue
+	var roots []string
+	if hasRequested {
+		for _, key := range order {
+			if r := installed[key].Requested; r != nil && *r {
+				roots = append(roots, key)
+			}
+		}
+	} else {
+		required := make(map[string]bool)
+		for _, key := range order {
+			for _, e := range requires(key, nil) {
+				required[e.name] = true
+			}
+		}
+		for _, key := range order {
+			if !required[key] {
+				roots = append(roots, key)
+			}
 		}
-		deps = append(deps, &resolve.Dep{
-			PURL:    resolve.MakePURL("conda", pkg.Name, pkg.Version),
-			Name:    pkg.Name,
-			Version: pkg.Version,
-		})
 	}
-	return deps, nil
-}
 
-// parseStack parses output from `stack ls dependencies json`.
-// Format: [{"name": "...", "version": "..."}, ...]
-func parseStack(data []byte) ([]*resolve.Dep, error) {
-	var packages []struct {
-		Name    string `json:"name"`
-		Version string `json:"version"`
-	}
-	if err := json.Unmarshal(data, &packages); err != nil {
-		return nil, fmt.Errorf("parsing stack output: %w", err)
+	// Extras change which requirements apply, so a package is only a repeat
+	// when it has been expanded with the same extras
+	seen := make(map[string]bool)
+	visited := make(map[string]bool)
+	var buildDep func(key string, extras []string) *resolve.Dep
+	buildDep = func(key string, extras []string) *resolve.Dep {
+		visited[key] = true
+		dep := newPipDep(installed[key])
+		seenKey := key + "[" + strings.Join(slices.Sorted(slices.Values(extras)), ",") + "]"
+		if seen[seenKey] {
+			dep.Deduped = true
+			return dep
+		}
+		seen[seenKey] = true
+		for _, e := range requires(key, extras) {
+			dep.Deps = append(dep.Deps, buildDep(e.name, e.extras))
+		}
+		return dep
 	}
 
 	var deps []*resolve.Dep
-	for _, pkg := range packages {
-		if pkg.Name == "" {
-			continue
+	for _, key := range roots {
+		deps = append(deps, buildDep(key, nil))
+	}
+	if !hasRequested {
+		for _, key := range order {
+			if !visited[key] {
+				deps = append(deps, buildDep(key, nil))
+			}
 		}
-		deps = append(deps, &resolve.Dep{
-			PURL:    resolve.MakePURL("hackage", pkg.Name, pkg.Version),
-			Name:    pkg.Name,
-			Version: pkg.Version,
-		})
 	}
 	return deps, nil
 }
 
+// newPipDep builds the Dep for an installed distribution.
+func newPipDep(dist *pipDist) *resolve.Dep {
+	meta := dist.Metadata
+	dep := &resolve.Dep{
+		Name:    meta.Name,
+		Version: meta.Version,
+		Deps:    []*resolve.Dep{},
+	}
+	qualifiers := map[string]string{}
+	if direct := dist.DirectURL; direct != nil {
+		switch {
+		case direct.VCSInfo != nil:
+			dep.Source = direct.VCSInfo.VCS
+			dep.Location = direct.URL
+			if direct.VCSInfo.CommitID != "" {
+				qualifiers["vcs_url"] = vcsURL(direct.VCSInfo.VCS, direct.URL, direct.VCSInfo.CommitID)
+			}
+		case strings.HasPrefix(direct.URL, "file://"):
+			// Local directories, editable installs and local archives
+			dep.Source = "path"
+			dep.Location = strings.TrimPrefix(direct.URL, "file://")
+			return dep
+		default:
+			dep.Source = "url"
+			dep.Location = direct.URL
+			qualifiers["download_url"] = direct.URL
+		}
+	}
+	dep.PURL = resolve.MakePURLWithQualifiers("pypi", meta.Name, meta.Version, qualifiers)
+	return dep
+}
+
 func init() {
 	resolve.Register("pip", "pypi", parsePip)
-	resolve.Register("conda", "conda", parseConda)
-	resolve.Register("stack", "hackage", parseStack)
 }
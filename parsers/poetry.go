@@ -8,86 +8,114 @@ import (
 )
 
 // poetryTopRe matches top-level lines like "requests 2.31.0 Description here".
-var poetryTopRe = regexp.MustCompile(`^(\S+)\s+(\S+)(?:\s+.*)?$`)
+// Packages that are not installed are flagged with "(!)" before the version.
+var poetryTopRe = regexp.MustCompile(`^(\S+)\s+(?:\(!\)\s+)?(\S+)(?:\s+.*)?$`)
 
-// poetrySubRe matches sub-dependency lines like "├── certifi (>=2017.4.17)".
-var poetrySubRe = regexp.MustCompile(`^(\S+)\s+`)
+// poetrySubRe matches sub-dependency content like "certifi >=2017.4.17" or
+// "certifi (>=2017.4.17)", capturing the name and declared constraint.
+var poetrySubRe = regexp.MustCompile(`^(\S+)(?:\s+(.*))?$`)
 
 // parsePoetry parses output from `poetry show --tree --no-ansi`.
 // Top-level packages appear on unindented lines: "name version description".
-// Sub-deps use box-drawing and show constraints, not resolved versions.
-// We cross-reference sub-deps against top-level entries for actual versions.
+// Sub-deps use box-drawing and show constraints, not resolved versions, so
+// versions are backfilled from unindented lines anywhere in the output. The
+// flat `poetry show` listing may be appended to supply versions for packages
+// that only appear nested; its column-aligned lines are not treated as roots.
+// Entries poetry marks "(circular dependency aborted here)" are Deduped, as in
+// composer.
 func parsePoetry(data []byte) ([]*resolve.Dep, error) {
 	lines := strings.Split(string(data), "\n")
+	listing := flatListingLines(lines)
 
 	// First pass: collect all top-level package versions
 	versions := make(map[string]string)
 	for _, line := range lines {
-		if line == "" || strings.HasPrefix(line, " ") || strings.HasPrefix(line, "│") ||
-			strings.HasPrefix(line, "├") || strings.HasPrefix(line, "└") {
+		if line == "" || isBoxTreeLine(line) {
 			continue
 		}
 		m := poetryTopRe.FindStringSubmatch(line)
 		if m != nil {
-			versions[strings.ToLower(m[1])] = m[2]
+			versions[resolve.NormalizePyPIName(m[1])] = m[2]
 		}
 	}
 
 	// Second pass: build tree
+	type stackEntry struct {
+		dep   *resolve.Dep
+		depth int
+	}
+
 	opts := resolve.BoxDrawingOptions()
 	var result []*resolve.Dep
-	var currentTop *resolve.Dep
+	var stack []stackEntry
+	roots := make(map[string]bool)
 
-	for _, line := range lines {
+	for i, line := range lines {
 		if line == "" {
 			continue
 		}
 
-		// Top-level line (no indentation, no box-drawing prefix)
-		if !strings.HasPrefix(line, " ") && !strings.HasPrefix(line, "│") &&
-			!strings.HasPrefix(line, "├") && !strings.HasPrefix(line, "└") {
+		if !isBoxTreeLine(line) {
+			stack = nil
+			if listing[i] {
+				continue
+			}
 			m := poetryTopRe.FindStringSubmatch(line)
 			if m == nil {
 				continue
 			}
-			currentTop = &resolve.Dep{
+			key := resolve.NormalizePyPIName(m[1])
+			if roots[key] {
+				continue
+			}
+			roots[key] = true
+			dep := &resolve.Dep{
 				PURL:    resolve.MakePURL("pypi", m[1], m[2]),
 				Name:    m[1],
 				Version: m[2],
 				Deps:    []*resolve.Dep{},
 			}
-			result = append(result, currentTop)
+			result = append(result, dep)
+			stack = []stackEntry{{dep: dep, depth: -1}}
 			continue
 		}
 
-		if currentTop == nil {
+		if len(stack) == 0 {
 			continue
 		}
 
-		// Sub-dependency line - parse with tree helper for depth
 		treeLines := resolve.ParseTreeLines([]string{line}, opts)
 		if len(treeLines) == 0 {
 			continue
 		}
+		tl := treeLines[0]
 
-		content := treeLines[0].Content
-		m := poetrySubRe.FindStringSubmatch(content)
+		m := poetrySubRe.FindStringSubmatch(tl.Content)
 		if m == nil {
 			continue
 		}
 
 		name := m[1]
-		version := versions[strings.ToLower(name)]
-
-		if treeLines[0].Depth == 0 {
-			// Direct sub-dep of current top-level package
-			currentTop.Deps = append(currentTop.Deps, &resolve.Dep{
-				PURL:    resolve.MakePURL("pypi", name, version),
-				Name:    name,
-				Version: version,
-				Deps:    []*resolve.Dep{},
-			})
+		constraint, circular := strings.CutSuffix(strings.TrimSpace(m[2]), listingCircular)
+		constraint = strings.TrimSpace(constraint)
+		constraint = strings.TrimSuffix(strings.TrimPrefix(constraint, "("), ")")
+		version := versions[resolve.NormalizePyPIName(name)]
+
+		dep := &resolve.Dep{
+			PURL:       resolve.MakePURL("pypi", name, version),
+			Name:       name,
+			Version:    version,
+			Constraint: constraint,
+			Deduped:    circular,
+			Deps:       []*resolve.Dep{},
+		}
+
+		for len(stack) > 1 && stack[len(stack)-1].depth >= tl.Depth {
+			stack = stack[:len(stack)-1]
 		}
+		parent := stack[len(stack)-1].dep
+		parent.Deps = append(parent.Deps, dep)
+		stack = append(stack, stackEntry{dep: dep, depth: tl.Depth})
 	}
 
 	return result, nil
@@ -1,6 +1,9 @@
 package parsers
 
 import (
+	"bytes"
+	"encoding/json"
+	"fmt"
 	"regexp"
 	"strings"
 
@@ -10,10 +13,62 @@ import (
 // pubPkgRe matches "name version" in pub deps output.
 var pubPkgRe = regexp.MustCompile(`^(\S+)\s+(\S+)`)
 
-// parsePub parses output from `dart pub deps`.
+// pubSections maps `dart pub deps` section headers to the scope of the
+// packages listed directly beneath them.
+var pubSections = map[string]string{
+	"dependencies:":         "",
+	"dev dependencies:":     "dev",
+	"dependency overrides:": "override",
+}
+
+// pubDefaultHost is the hosted source that gets no repository_url qualifier.
+const pubDefaultHost = "https://pub.dev"
+
+// parsePub parses output from `dart pub deps`, or from `dart pub deps --json`.
 // Box-drawing tree with ├── and └── markers. Packages formatted as "name version".
+// Packages under the "dev dependencies:" header get Scope "dev", and those
+// under "dependency overrides:" Scope "override".
 func parsePub(data []byte) ([]*resolve.Dep, error) {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		return parsePubJSON(trimmed)
+	}
+
 	lines := strings.Split(string(data), "\n")
+	parse := func(content string) (*resolve.Dep, bool) {
+		m := pubPkgRe.FindStringSubmatch(content)
+		if m == nil {
+			return nil, false
+		}
+		return &resolve.Dep{PURL: resolve.MakePURL("pub", m[1], m[2]), Name: m[1], Version: m[2]}, true
+	}
+
+	// Split into sections when the output has headers
+	var deps []*resolve.Dep
+	var section []string
+	scope, sectioned := "", false
+	flush := func() {
+		for _, dep := range resolve.BuildDepTree(resolve.ParseTreeLines(section, resolve.BoxDrawingOptions()), parse) {
+			dep.Scope = scope
+			deps = append(deps, dep)
+		}
+		section = nil
+	}
+	for _, line := range lines {
+		if s, ok := pubSections[strings.TrimSpace(line)]; ok {
+			if sectioned {
+				flush()
+			}
+			scope, sectioned = s, true
+			continue
+		}
+		if sectioned {
+			section = append(section, line)
+		}
+	}
+	if sectioned {
+		flush()
+		return deps, nil
+	}
 
 	// Skip header lines (everything before the first tree marker or package line)
 	var treeStart int
@@ -36,14 +91,184 @@ func parsePub(data []byte) ([]*resolve.Dep, error) {
 
 	opts := resolve.BoxDrawingOptions()
 	treeLines := resolve.ParseTreeLines(lines[treeStart:], opts)
+	return resolve.BuildDepTree(treeLines, parse), nil
+}
 
-	return resolve.BuildTree(treeLines, "pub", func(content string) (string, string, bool) {
-		m := pubPkgRe.FindStringSubmatch(content)
-		if m == nil {
-			return "", "", false
+// pubJSONPackage is an entry in the packages list of `dart pub deps --json`.
+type pubJSONPackage struct {
+	Name            string   `json:"name"`
+	Version         string   `json:"version"`
+	Kind            string   `json:"kind"`
+	Source          string   `json:"source"`
+	Dependencies    []string `json:"dependencies"`
+	DevDependencies []string `json:"devDependencies"`
+}
+
+// pubDescription is a package's description from pubspec.lock: the SDK name
+// for sdk packages, the server URL for hosted ones, the repository URL and
+// resolved-ref for git ones, and the path for path ones.
+type pubDescription struct {
+	sdk, url, path, resolvedRef string
+}
+
+// parsePubJSON reads `dart pub deps --json`. The root package's dependencies
+// and dev dependencies are the direct deps, and packages of kind "dev" get
+// Scope "dev". Packages from the Flutter or Dart SDK get Source "sdk" and no
+// PURL, git packages Source "git", and path packages Source "path" and no
+// PURL. The JSON doesn't say where packages come from beyond that, so
+// pubspec.lock contents may be appended after it: they give git deps a
+// vcs_url, path deps their Location, and hosted packages from a server other
+// than pub.dev a repository_url qualifier.
+func parsePubJSON(data []byte) ([]*resolve.Dep, error) {
+	var output struct {
+		Root     string           `json:"root"`
+		Packages []pubJSONPackage `json:"packages"`
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	if err := decoder.Decode(&output); err != nil {
+		return nil, fmt.Errorf("parsing dart pub deps output: %w", err)
+	}
+	lock := readPubspecLock(string(data[decoder.InputOffset():]))
+
+	packages := make(map[string]*pubJSONPackage, len(output.Packages))
+	var root *pubJSONPackage
+	for i := range output.Packages {
+		pkg := &output.Packages[i]
+		packages[pkg.Name] = pkg
+		if pkg.Kind == "root" || (root == nil && pkg.Name == output.Root) {
+			root = pkg
 		}
-		return m[1], m[2], true
-	}), nil
+	}
+
+	seen := make(map[string]bool)
+	var buildDep func(name string) *resolve.Dep
+	buildDep = func(name string) *resolve.Dep {
+		pkg, ok := packages[name]
+		if !ok {
+			return &resolve.Dep{PURL: resolve.MakePURL("pub", name, ""), Name: name, Deps: []*resolve.Dep{}}
+		}
+		dep := newPubDep(pkg, lock[name])
+		if seen[name] {
+			dep.Deduped = len(pkg.Dependencies) > 0
+			return dep
+		}
+		seen[name] = true
+		for _, child := range pkg.Dependencies {
+			dep.Deps = append(dep.Deps, buildDep(child))
+		}
+		return dep
+	}
+
+	deps := []*resolve.Dep{}
+	if root != nil {
+		seen[root.Name] = true
+		for _, name := range append(root.Dependencies, root.DevDependencies...) {
+			if !seen[name] {
+				deps = append(deps, buildDep(name))
+			}
+		}
+	}
+	// Direct packages the root entry didn't list, and anything unreachable
+	for _, pkg := range output.Packages {
+		if !seen[pkg.Name] {
+			deps = append(deps, buildDep(pkg.Name))
+		}
+	}
+	return deps, nil
+}
+
+func newPubDep(pkg *pubJSONPackage, desc pubDescription) *resolve.Dep {
+	dep := &resolve.Dep{
+		Name:    pkg.Name,
+		Version: pkg.Version,
+		Deps:    []*resolve.Dep{},
+	}
+	if pkg.Kind == "dev" {
+		dep.Scope = "dev"
+	}
+
+	qualifiers := map[string]string{}
+	switch pkg.Source {
+	case "sdk":
+		dep.Source = "sdk"
+		dep.Location = desc.sdk
+		return dep
+	case "git":
+		dep.Source = "git"
+		dep.Location = desc.url
+		if desc.url != "" && desc.resolvedRef != "" {
+			qualifiers["vcs_url"] = gitVCSURL(desc.url, desc.resolvedRef)
+		}
+	case "path":
+		dep.Source = "path"
+		dep.Location = desc.path
+		return dep
+	case "hosted":
+		if url := strings.TrimSuffix(desc.url, "/"); url != "" && url != pubDefaultHost && url != "https://pub.dartlang.org" {
+			qualifiers["repository_url"] = url
+		}
+	}
+	dep.PURL = resolve.MakePURLWithQualifiers("pub", pkg.Name, pkg.Version, qualifiers)
+	return dep
+}
+
+// Indentation of package names and their fields under "packages:" in
+// pubspec.lock.
+const (
+	pubLockNameIndent  = 2
+	pubLockFieldIndent = 4
+)
+
+// readPubspecLock reads the package descriptions from pubspec.lock, which
+// looks like
+//
+//	packages:
+//	  http:
+//	    dependency: "direct main"
+//	    description:
+//	      name: http
+//	      url: "https://pub.dev"
+//	    source: hosted
+//	    version: "1.1.2"
+//
+// with sdk descriptions written inline ("description: flutter").
+func readPubspecLock(text string) map[string]pubDescription {
+	lock := make(map[string]pubDescription)
+	var name string
+	inPackages, inDescription := false, false
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, " \r")
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		key, value, _ := strings.Cut(trimmed, ":")
+		value = strings.Trim(strings.TrimSpace(value), `"'`)
+		switch indent := len(line) - len(strings.TrimLeft(line, " ")); {
+		case indent == 0:
+			inPackages = key == "packages"
+		case !inPackages:
+		case indent <= pubLockNameIndent:
+			name, inDescription = key, false
+		case indent <= pubLockFieldIndent:
+			inDescription = key == "description"
+			if inDescription && value != "" {
+				lock[name] = pubDescription{sdk: value}
+			}
+		case inDescription:
+			desc := lock[name]
+			switch key {
+			case "url":
+				desc.url = value
+			case "path":
+				desc.path = value
+			case "resolved-ref":
+				desc.resolvedRef = value
+			}
+			lock[name] = desc
+		}
+	}
+	return lock
 }
 
 func init() {
@@ -1,6 +1,7 @@
 package parsers
 
 import (
+	"fmt"
 	"regexp"
 	"strings"
 
@@ -8,27 +9,125 @@ import (
 )
 
 // rebar3PkgRe matches "name─version" or "name─version (hex package)".
-var rebar3PkgRe = regexp.MustCompile(`^(\S+?)─(\S+?)(?:\s|$)`)
+var rebar3PkgRe = regexp.MustCompile(`^(\S+?)─(\S+?)(?:\s+\(([^)]*)\))?\s*$`)
 
 // parseRebar3 parses output from `rebar3 tree`.
-// Lines like "├─ name─version (hex package)" with single-width dashes.
+// Lines like "├─ name─version (hex package)" with single-width dashes. The
+// annotation says where the app comes from: "(git repo)" gets Source "git"
+// and "(project app)", an app in the project's own umbrella, gets Source
+// "workspace" and no PURL. `rebar3 tree` doesn't show git URLs, so git deps
+// only get a vcs_url when rebar.lock contents are appended after the tree, as
+// with mix deps.tree and mix.lock. It doesn't name private Hex organisations
+// either; use rebar-lock for those.
 func parseRebar3(data []byte) ([]*resolve.Dep, error) {
-	lines := strings.Split(string(data), "\n")
+	text := string(data)
+	locked := make(map[string]*resolve.Dep)
+	if idx := rebarLockStart(text); idx >= 0 {
+		if deps, err := parseRebarLock([]byte(text[idx:])); err == nil {
+			for _, dep := range deps {
+				locked[dep.Name] = dep
+			}
+		}
+		text = text[:idx]
+	}
+
+	lines := strings.Split(text, "\n")
 	opts := resolve.TreeOptions{
 		Prefixes:      []string{"├─ ", "└─ "},
 		Continuations: []string{"│  ", "   "},
 	}
 	treeLines := resolve.ParseTreeLines(lines, opts)
 
-	return resolve.BuildTree(treeLines, "hex", func(content string) (string, string, bool) {
-		m := rebar3PkgRe.FindStringSubmatch(content)
+	return resolve.BuildDepTree(treeLines, func(content string) (*resolve.Dep, bool) {
+		m := rebar3PkgRe.FindStringSubmatch(strings.TrimSpace(content))
 		if m == nil {
-			return "", "", false
+			return nil, false
 		}
-		return m[1], m[2], true
+		dep := &resolve.Dep{Name: m[1], Version: m[2]}
+		switch m[3] {
+		case "git repo":
+			lock := locked[dep.Name]
+			if lock == nil || lock.Source != "git" {
+				setHexGit(dep, "", "")
+				break
+			}
+			ref := lock.Version
+			if ref == "" {
+				ref = lock.Constraint
+			}
+			setHexGit(dep, lock.Location, ref)
+		case "project app":
+			dep.Source = "workspace"
+		default:
+			dep.PURL = resolve.MakePURL("hex", dep.Name, dep.Version)
+		}
+		return dep, true
 	}), nil
 }
 
+// rebarLockStart returns the offset of rebar.lock contents appended after
+// `rebar3 tree` output, which start with "{" or "[" at the start of a line,
+// or -1 if there are none.
+func rebarLockStart(text string) int {
+	offset := 0
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if strings.HasPrefix(line, "{") || strings.HasPrefix(line, "[") {
+			return offset
+		}
+		offset += len(line)
+	}
+	return -1
+}
+
+// parseRebarLock parses a rebar.lock file: {"1.2.0", [Locks]}. followed by
+// a hash table, or a bare list of locks from rebar3 versions before 3.5.
+// Each lock is {<<"name">>, Source, Level}, where level 0 marks a direct
+// dependency. The lock records no edges, so deps are returned as a flat list
+// with transitive ones marked Indirect. Hex sources are
+// {pkg, <<"pkg">>, <<"version">>} with an optional repository after the
+// version; git sources are {git, "url", {ref|tag|branch, "..."}} and use the
+// ref or tag as the version, or the branch as the constraint.
+func parseRebarLock(data []byte) ([]*resolve.Dep, error) {
+	terms := parseBeamTerms(string(data))
+	if len(terms) == 0 {
+		return nil, fmt.Errorf("parsing rebar.lock: no terms found")
+	}
+	locks := terms[0]
+	if locks.kind == 't' {
+		locks = locks.item(1)
+	}
+	if locks.kind != 'l' {
+		return nil, fmt.Errorf("parsing rebar.lock: expected a list of locks")
+	}
+
+	var deps []*resolve.Dep
+	for _, lock := range locks.items {
+		name, source := lock.item(0).str(), lock.item(1)
+		if name == "" {
+			continue
+		}
+		dep := &resolve.Dep{Name: name, Indirect: lock.item(2).text != "0"}
+		switch source.item(0).str() {
+		case "pkg":
+			dep.Version = source.item(2).str()
+			setHexPackage(dep, source.item(1).str(), source.item(3).str())
+		case "git":
+			ref := source.item(2).item(1).str()
+			if source.item(2).item(0).str() == "branch" {
+				dep.Constraint = ref
+			} else {
+				dep.Version = ref
+			}
+			setHexGit(dep, source.item(1).str(), ref)
+		default:
+			dep.PURL = resolve.MakePURL("hex", name, "")
+		}
+		deps = append(deps, dep)
+	}
+	return deps, nil
+}
+
 func init() {
 	resolve.Register("rebar3", "hex", parseRebar3)
+	resolve.Register("rebar-lock", "hex", parseRebarLock)
 }
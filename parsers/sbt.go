@@ -0,0 +1,281 @@
+package parsers
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/git-pkgs/resolve"
+)
+
+// sbtModuleRe matches a module as sbt shows it:
+// "com.typesafe.akka:akka-actor_2.13:2.6.20 [S]" or
+// "org.slf4j:slf4j-api:1.7.30 (evicted by: 1.7.36)".
+var sbtModuleRe = regexp.MustCompile(`^([^:\s]+):([^:\s]+):(\S+)((?:\s+\[S\])?)(?:\s+\(evicted by: ([^)]+)\))?`)
+
+// sbtCrossVersionRe matches the Scala cross-version suffix of an artifact
+// id: "_2.13", "_3", or "_sjs1_2.13" and "_native0.4_3" for Scala.js and
+// Scala Native.
+var sbtCrossVersionRe = regexp.MustCompile(`_((?:sjs\d+_|native[\d.]+_)?(?:2\.1[0-3]|3))$`)
+
+// sbtDotNodeRe and sbtDotEdgeRe match `dependencyDot` node and edge lines.
+var (
+	sbtDotNodeRe = regexp.MustCompile(`^"([^"]+)"\s*\[`)
+	sbtDotEdgeRe = regexp.MustCompile(`^"([^"]+)"\s*->\s*"([^"]+)"(.*)$`)
+)
+
+// sbtNode is a module with its children, as read from any of sbt's formats.
+type sbtNode struct {
+	text     string
+	children []*sbtNode
+	deduped  bool
+}
+
+// parseSbt parses output from `sbt dependencyTree`, the JSON written by
+// `dependencyBrowseTreeHTML` (tree.json or tree.data.js), or `dependencyDot`.
+// Each top-level module is an sbt project: with one project its dependencies
+// are returned directly, otherwise each project is a root with Source
+// "workspace". Names are Maven "group:artifact" coordinates and keep the
+// Scala cross-version suffix, which is the real artifact id; the Scala
+// version it names ("2.13", "3", "sjs1_2.13") is also set as Target. An
+// evicted module ("(evicted by: 1.7.36)") has the version that replaced it
+// as Version, the version that was asked for as Constraint, and an
+// "evicted" diagnostic.
+func parseSbt(data []byte) ([]*resolve.Dep, error) {
+	trimmed := bytes.TrimSpace(data)
+	var roots []*sbtNode
+	switch {
+	case bytes.HasPrefix(trimmed, []byte("digraph")):
+		roots = parseSbtDot(trimmed)
+	case isSbtJSON(trimmed):
+		var err error
+		if roots, err = parseSbtJSON(trimmed); err != nil {
+			return nil, err
+		}
+	default:
+		roots = parseSbtText(data)
+	}
+
+	var build func(n *sbtNode) *resolve.Dep
+	build = func(n *sbtNode) *resolve.Dep {
+		dep, ok := parseSbtModule(n.text)
+		if !ok {
+			return nil
+		}
+		dep.Deps = []*resolve.Dep{}
+		dep.Deduped = n.deduped
+		for _, child := range n.children {
+			if c := build(child); c != nil {
+				dep.Deps = append(dep.Deps, c)
+			}
+		}
+		return dep
+	}
+
+	var projects []*resolve.Dep
+	for _, root := range roots {
+		if dep := build(root); dep != nil {
+			projects = append(projects, dep)
+		}
+	}
+	if len(projects) == 1 {
+		return projects[0].Deps, nil
+	}
+	for _, project := range projects {
+		project.Source = "workspace"
+	}
+	return projects, nil
+}
+
+// isSbtJSON tells the browse tree JSON from `dependencyTree` text, whose
+// lines also start with "[" for the log level.
+func isSbtJSON(data []byte) bool {
+	if bytes.HasPrefix(data, []byte("tree_data")) || bytes.HasPrefix(data, []byte("{")) {
+		return true
+	}
+	rest, ok := bytes.CutPrefix(data, []byte("["))
+	rest = bytes.TrimSpace(rest)
+	return ok && len(rest) > 0 && (rest[0] == '{' || rest[0] == ']')
+}
+
+// parseSbtModule parses the text of one module.
+func parseSbtModule(text string) (*resolve.Dep, bool) {
+	m := sbtModuleRe.FindStringSubmatch(strings.TrimSpace(text))
+	if m == nil {
+		return nil, false
+	}
+	group, artifact, version, evictedBy := m[1], m[2], m[3], m[5]
+	dep := &resolve.Dep{
+		Name:    group + ":" + artifact,
+		Version: version,
+	}
+	if cv := sbtCrossVersionRe.FindStringSubmatch(artifact); cv != nil {
+		dep.Target = cv[1]
+	}
+	if evictedBy != "" {
+		dep.Constraint = version
+		dep.Version = evictedBy
+		dep.Diagnostics = append(dep.Diagnostics, resolve.Diagnostic{
+			Kind:    "evicted",
+			Message: "evicted by: " + evictedBy,
+		})
+	}
+	dep.PURL = resolve.MakePURL("maven", dep.Name, dep.Version)
+	return dep, true
+}
+
+// parseSbtText reads the `dependencyTree` layout, where each level is
+// indented two more columns and entries start with "+-":
+//
+//	[info] com.example:app_2.13:0.1.0 [S]
+//	[info]   +-com.typesafe:config:1.4.2
+//	[info]   | +-...
+func parseSbtText(data []byte) []*sbtNode {
+	var roots []*sbtNode
+	type stackEntry struct {
+		node  *sbtNode
+		depth int
+	}
+	var stack []stackEntry
+
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimRight(line, " \r")
+		if rest, ok := strings.CutPrefix(line, "[info] "); ok {
+			line = rest
+		} else if strings.HasPrefix(line, "[") {
+			// [warn], [success] and the like
+			continue
+		}
+
+		idx := strings.Index(line, "+-")
+		if idx < 0 {
+			// Unindented module lines are projects; other lines are sbt chatter
+			if !sbtModuleRe.MatchString(line) {
+				continue
+			}
+			node := &sbtNode{text: line}
+			roots = append(roots, node)
+			stack = []stackEntry{{node: node, depth: 0}}
+			continue
+		}
+		if len(stack) == 0 || strings.Trim(line[:idx], " |") != "" {
+			continue
+		}
+
+		depth := idx/2 + 1
+		node := &sbtNode{text: line[idx+2:]}
+		for len(stack) > 1 && stack[len(stack)-1].depth >= depth {
+			stack = stack[:len(stack)-1]
+		}
+		parent := stack[len(stack)-1].node
+		parent.children = append(parent.children, node)
+		stack = append(stack, stackEntry{node: node, depth: depth})
+	}
+	return roots
+}
+
+// parseSbtJSON reads the tree written by `dependencyBrowseTreeHTML`, an
+// array of {"text", "children"} objects, optionally wrapped as the
+// "tree_data = [...];" script of tree.data.js.
+func parseSbtJSON(data []byte) ([]*sbtNode, error) {
+	if _, rest, ok := bytes.Cut(data, []byte("=")); ok && bytes.HasPrefix(data, []byte("tree_data")) {
+		data = bytes.TrimSuffix(bytes.TrimSpace(rest), []byte(";"))
+	}
+	type jsonNode struct {
+		Text     string     `json:"text"`
+		Children []jsonNode `json:"children"`
+	}
+	var nodes []jsonNode
+	if data[0] == '{' {
+		var node jsonNode
+		if err := json.Unmarshal(data, &node); err != nil {
+			return nil, fmt.Errorf("parsing sbt dependency tree: %w", err)
+		}
+		nodes = []jsonNode{node}
+	} else if err := json.Unmarshal(data, &nodes); err != nil {
+		return nil, fmt.Errorf("parsing sbt dependency tree: %w", err)
+	}
+
+	var convert func(n jsonNode) *sbtNode
+	convert = func(n jsonNode) *sbtNode {
+		node := &sbtNode{text: n.Text}
+		for _, child := range n.Children {
+			node.children = append(node.children, convert(child))
+		}
+		return node
+	}
+	var roots []*sbtNode
+	for _, n := range nodes {
+		roots = append(roots, convert(n))
+	}
+	return roots, nil
+}
+
+// parseSbtDot reads the graph written by `dependencyDot`. Nodes are quoted
+// "group:artifact:version" ids, and an edge labelled "Evicted By" points from
+// an evicted module to the one that replaced it. Modules nothing depends on
+// are the projects. A module reached twice is expanded the first time only.
+func parseSbtDot(data []byte) []*sbtNode {
+	var order []string
+	known := make(map[string]bool)
+	edges := make(map[string][]string)
+	evictedBy := make(map[string]string)
+	incoming := make(map[string]bool)
+	addNode := func(id string) {
+		if !known[id] {
+			known[id] = true
+			order = append(order, id)
+		}
+	}
+
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if m := sbtDotEdgeRe.FindStringSubmatch(line); m != nil {
+			from, to := m[1], m[2]
+			addNode(from)
+			addNode(to)
+			if strings.Contains(m[3], "Evicted By") {
+				evictedBy[from] = to[strings.LastIndex(to, ":")+1:]
+				continue
+			}
+			edges[from] = append(edges[from], to)
+			incoming[to] = true
+			continue
+		}
+		if m := sbtDotNodeRe.FindStringSubmatch(line); m != nil {
+			addNode(m[1])
+		}
+	}
+
+	seen := make(map[string]bool)
+	var build func(id string) *sbtNode
+	build = func(id string) *sbtNode {
+		node := &sbtNode{text: id}
+		if v := evictedBy[id]; v != "" {
+			node.text += " (evicted by: " + v + ")"
+		}
+		if seen[id] {
+			node.deduped = len(edges[id]) > 0
+			return node
+		}
+		seen[id] = true
+		for _, child := range edges[id] {
+			node.children = append(node.children, build(child))
+		}
+		return node
+	}
+
+	var roots []*sbtNode
+	for _, id := range order {
+		if !incoming[id] && evictedBy[id] == "" {
+			roots = append(roots, build(id))
+		}
+	}
+	return roots
+}
+
+func init() {
+	resolve.Register("sbt", "maven", parseSbt)
+}
@@ -3,18 +3,26 @@ package parsers
 import (
 	"encoding/json"
 	"fmt"
+	"strings"
 
 	"github.com/git-pkgs/resolve"
 )
 
 // swiftPackage represents a package in swift's JSON output.
 type swiftPackage struct {
+	Identity     string         `json:"identity"`
 	Name         string         `json:"name"`
+	URL          string         `json:"url"`
 	Version      string         `json:"version"`
 	Dependencies []swiftPackage `json:"dependencies"`
 }
 
 // parseSwift parses output from `swift package show-dependencies --format json`.
+// PURLs take their namespace and name from the package URL, so
+// https://github.com/apple/swift-nio.git becomes
+// pkg:swift/github.com/apple/swift-nio. Packages pinned to a branch or
+// revision are reported as "unspecified" and get an empty Version, as in
+// swift-resolved; local packages are marked as path sources and get no PURL.
 func parseSwift(data []byte) ([]*resolve.Dep, error) {
 	var root swiftPackage
 	if err := json.Unmarshal(data, &root); err != nil {
@@ -27,12 +35,16 @@ func parseSwift(data []byte) ([]*resolve.Dep, error) {
 func walkSwiftDeps(pkgs []swiftPackage) []*resolve.Dep {
 	var result []*resolve.Dep
 	for _, pkg := range pkgs {
-		dep := &resolve.Dep{
-			PURL:    resolve.MakePURL("swift", pkg.Name, pkg.Version),
-			Name:    pkg.Name,
-			Version: pkg.Version,
-			Deps:    []*resolve.Dep{},
+		version := pkg.Version
+		if version == "unspecified" {
+			version = ""
 		}
+		name := pkg.Name
+		if name == "" {
+			name = pkg.Identity
+		}
+		dep := newSwiftDep(name, pkg.URL, version, "")
+		dep.Deps = []*resolve.Dep{}
 		if len(pkg.Dependencies) > 0 {
 			dep.Deps = walkSwiftDeps(pkg.Dependencies)
 		}
@@ -41,6 +53,92 @@ func walkSwiftDeps(pkgs []swiftPackage) []*resolve.Dep {
 	return result
 }
 
+// newSwiftDep builds a Dep for a package with the given source location. A
+// package pinned to a revision rather than a version gets the revision in a
+// vcs_url qualifier. Local packages have no URL to take a namespace from,
+// which the swift PURL type requires, so they get no PURL.
+func newSwiftDep(name, location, version, revision string) *resolve.Dep {
+	dep := &resolve.Dep{Name: name, Version: version}
+	purlName := gitRepoPath(location)
+	if purlName == "" {
+		if location != "" {
+			dep.Source = "path"
+			dep.Location = location
+		}
+		return dep
+	}
+	qualifiers := map[string]string{}
+	if version == "" && revision != "" {
+		qualifiers["vcs_url"] = gitVCSURL(location, revision)
+	}
+	dep.PURL = resolve.MakePURLWithQualifiers("swift", purlName, version, qualifiers)
+	return dep
+}
+
+// parseSwiftResolved parses a Package.resolved file. Version 1 files nest
+// pins under "object" and use "package" and "repositoryURL"; versions 2 and 3
+// use "identity", "kind" and "location". Pins without a version (branch or
+// revision pins) get an empty Version, as in show-dependencies output, with
+// the pinned revision in a vcs_url qualifier and the branch, when there is
+// one, as Constraint. Registry pins ("scope.name") use the scope as
+// the PURL namespace.
+func parseSwiftResolved(data []byte) ([]*resolve.Dep, error) {
+	type swiftPin struct {
+		Package       string `json:"package"`
+		RepositoryURL string `json:"repositoryURL"`
+		Identity      string `json:"identity"`
+		Kind          string `json:"kind"`
+		Location      string `json:"location"`
+		State         struct {
+			Branch   string `json:"branch"`
+			Revision string `json:"revision"`
+			Version  string `json:"version"`
+		} `json:"state"`
+	}
+	var resolved struct {
+		Version int        `json:"version"`
+		Pins    []swiftPin `json:"pins"`
+		Object  struct {
+			Pins []swiftPin `json:"pins"`
+		} `json:"object"`
+	}
+	if err := json.Unmarshal(data, &resolved); err != nil {
+		return nil, fmt.Errorf("parsing Package.resolved: %w", err)
+	}
+
+	pins := resolved.Pins
+	if resolved.Version == 1 {
+		pins = resolved.Object.Pins
+	}
+
+	var deps []*resolve.Dep
+	for _, pin := range pins {
+		name, location := pin.Identity, pin.Location
+		if resolved.Version == 1 {
+			name, location = pin.Package, pin.RepositoryURL
+		}
+		version := pin.State.Version
+
+		var dep *resolve.Dep
+		if pin.Kind == "registry" {
+			scope, pkgName, _ := strings.Cut(name, ".")
+			dep = &resolve.Dep{
+				PURL:    resolve.MakePURL("swift", scope+"/"+pkgName, version),
+				Name:    name,
+				Version: version,
+			}
+		} else {
+			dep = newSwiftDep(name, location, version, pin.State.Revision)
+		}
+		if pin.State.Version == "" {
+			dep.Constraint = pin.State.Branch
+		}
+		deps = append(deps, dep)
+	}
+	return deps, nil
+}
+
 func init() {
 	resolve.Register("swift", "swift", parseSwift)
+	resolve.Register("swift-resolved", "swift", parseSwiftResolved)
 }
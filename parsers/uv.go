@@ -1,30 +1,230 @@
 package parsers
 
 import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
 	"regexp"
 	"strings"
 
 	"github.com/git-pkgs/resolve"
 )
 
-// uvPkgRe matches lines like "certifi v2024.12.14" or "requests v2.31.0".
-var uvPkgRe = regexp.MustCompile(`^(\S+)\s+v(.+)$`)
+// uvPkgRe matches lines like "certifi v2024.12.14" or "requests[socks] v2.31.0",
+// capturing any trailing annotations separately.
+var uvPkgRe = regexp.MustCompile(`^([^\s\[]+)(?:\[[^\]]*\])?\s+v(\S+)(.*)$`)
+
+// uvAnnotationRe matches "(extra: socks)", "(group: dev)" and "(*)" annotations.
+var uvAnnotationRe = regexp.MustCompile(`\((?:(\w+): ([^)]*)|\*)\)`)
+
+// uvRequiredRe matches the "[required: >=2.0]" annotation from --show-version-specifiers.
+var uvRequiredRe = regexp.MustCompile(`\[required: ([^\]]*)\]`)
 
 // parseUV parses output from `uv tree`.
+// Unprefixed lines are the project or workspace members; their children are
+// returned as direct deps. Annotations after the version mark deduplicated
+// subtrees "(*)", deps pulled in by an extra of the parent "(extra: socks)" and
+// dependency groups "(group: dev)".
 func parseUV(data []byte) ([]*resolve.Dep, error) {
-	lines := strings.Split(string(data), "\n")
 	opts := resolve.BoxDrawingOptions()
+	var lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if hasTreePrefix(line, opts) {
+			lines = append(lines, line)
+		}
+	}
 	treeLines := resolve.ParseTreeLines(lines, opts)
 
-	return resolve.BuildTree(treeLines, "pypi", func(content string) (string, string, bool) {
+	return resolve.BuildDepTree(treeLines, func(content string) (*resolve.Dep, bool) {
 		m := uvPkgRe.FindStringSubmatch(content)
 		if m == nil {
-			return "", "", false
+			return nil, false
+		}
+		dep := &resolve.Dep{
+			PURL:    resolve.MakePURL("pypi", m[1], m[2]),
+			Name:    m[1],
+			Version: m[2],
+		}
+		for _, a := range uvAnnotationRe.FindAllStringSubmatch(m[3], -1) {
+			switch a[1] {
+			case "":
+				dep.Deduped = true
+			case "extra":
+				dep.Extra = a[2]
+			case "group":
+				dep.Scope = a[2]
+			}
 		}
-		return m[1], m[2], true
+		if r := uvRequiredRe.FindStringSubmatch(m[3]); r != nil {
+			dep.Constraint = r[1]
+		}
+		return dep, true
 	}), nil
 }
 
+// hasTreePrefix reports whether a line starts with a tree marker or continuation.
+func hasTreePrefix(line string, opts resolve.TreeOptions) bool {
+	for _, p := range append(opts.Prefixes, opts.Continuations...) {
+		if strings.HasPrefix(line, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseUVPip parses output from `uv pip list --format json`.
+// Format: [{"name": "...", "version": "..."}, ...]
+func parseUVPip(data []byte) ([]*resolve.Dep, error) {
+	var packages []struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal(data, &packages); err != nil {
+		return nil, fmt.Errorf("parsing uv pip list output: %w", err)
+	}
+
+	var deps []*resolve.Dep
+	for _, pkg := range packages {
+		if pkg.Name == "" {
+			continue
+		}
+		deps = append(deps, &resolve.Dep{
+			PURL:    resolve.MakePURL("pypi", pkg.Name, pkg.Version),
+			Name:    pkg.Name,
+			Version: pkg.Version,
+		})
+	}
+	return deps, nil
+}
+
+// uvExportReqRe matches pinned requirement lines like
+// "requests[socks]==2.31.0 ; python_version >= '3.8' \".
+var uvExportReqRe = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9._-]*)(?:\[[^\]]*\])?==(\S+?)(?:\s*;.*?)?\s*\\?$`)
+
+// uvExportViaRe matches "# via requests" annotations and the "#   requests"
+// continuation lines of a multi-line via block.
+var uvExportViaRe = regexp.MustCompile(`^#\s+(?:via\s+)?(\S.*)$`)
+
+// parseUVExport parses output from `uv export --format requirements-txt`.
+// Each package is pinned with "==" and followed by indented "--hash" and
+// "# via" lines. When via annotations are present they are used to rebuild the
+// tree, with packages required by the project itself as direct deps; otherwise
+// the packages are returned as a flat list.
+func parseUVExport(data []byte) ([]*resolve.Dep, error) {
+	type exportPkg struct {
+		name, version string
+		via           []string
+	}
+
+	var pkgs []*exportPkg
+	byName := make(map[string]*exportPkg)
+	var current *exportPkg
+	inVia := false
+	annotated := false
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := scanner.Text()
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" {
+			continue
+		}
+
+		if line[0] != ' ' && line[0] != '\t' {
+			inVia = false
+			current = nil
+			m := uvExportReqRe.FindStringSubmatch(trimmed)
+			if m == nil {
+				continue
+			}
+			current = &exportPkg{name: m[1], version: m[2]}
+			pkgs = append(pkgs, current)
+			byName[resolve.NormalizePyPIName(m[1])] = current
+			continue
+		}
+
+		if current == nil || !strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		if strings.HasPrefix(trimmed, "# via") {
+			annotated = true
+			inVia = true
+		} else if !inVia {
+			continue
+		}
+		m := uvExportViaRe.FindStringSubmatch(trimmed)
+		if m == nil || m[1] == "via" {
+			continue
+		}
+		current.via = append(current.via, m[1])
+	}
+
+	if !annotated {
+		var deps []*resolve.Dep
+		for _, pkg := range pkgs {
+			deps = append(deps, &resolve.Dep{
+				PURL:    resolve.MakePURL("pypi", pkg.name, pkg.version),
+				Name:    pkg.name,
+				Version: pkg.version,
+			})
+		}
+		return deps, nil
+	}
+
+	children := make(map[string][]string)
+	var roots []string
+	for _, pkg := range pkgs {
+		key := resolve.NormalizePyPIName(pkg.name)
+		direct := len(pkg.via) == 0
+		for _, via := range pkg.via {
+			// "requests[socks]", "-r requirements.in", "my-project (dev)"
+			fields := strings.Fields(strings.Split(via, "[")[0])
+			if len(fields) == 0 {
+				continue
+			}
+			parent := resolve.NormalizePyPIName(fields[0])
+			if _, ok := byName[parent]; ok && parent != key {
+				children[parent] = append(children[parent], key)
+			} else {
+				direct = true
+			}
+		}
+		if direct {
+			roots = append(roots, key)
+		}
+	}
+
+	seen := make(map[string]bool)
+	var buildDep func(key string) *resolve.Dep
+	buildDep = func(key string) *resolve.Dep {
+		pkg := byName[key]
+		dep := &resolve.Dep{
+			PURL:    resolve.MakePURL("pypi", pkg.name, pkg.version),
+			Name:    pkg.name,
+			Version: pkg.version,
+			Deps:    []*resolve.Dep{},
+		}
+		if seen[key] {
+			dep.Deduped = true
+			return dep
+		}
+		seen[key] = true
+		for _, child := range children[key] {
+			dep.Deps = append(dep.Deps, buildDep(child))
+		}
+		return dep
+	}
+
+	var deps []*resolve.Dep
+	for _, key := range roots {
+		deps = append(deps, buildDep(key))
+	}
+	return deps, nil
+}
+
 func init() {
 	resolve.Register("uv", "pypi", parseUV)
+	resolve.Register("uv-pip", "pypi", parseUVPip)
+	resolve.Register("uv-export", "pypi", parseUVExport)
 }
@@ -0,0 +1,51 @@
+package parsers
+
+import (
+	"net/url"
+	"strings"
+)
+
+// gitVCSURL returns the purl-spec vcs_url for a git remote at ref, such as
+// "git+https://github.com/apple/swift-log@e97a6fc". scp-style remotes
+// ("git@github.com:org/repo.git") are rewritten as ssh:// URLs first, and
+// remotes that already carry a "git+" prefix keep just the one.
+func gitVCSURL(remote, ref string) string {
+	return "git+" + normalizeGitRemote(strings.TrimPrefix(remote, "git+")) + "@" + ref
+}
+
+// vcsURL returns the vcs_url for a checkout from a repository of the given
+// type ("git", "hg", ...) at ref.
+func vcsURL(vcs, location, ref string) string {
+	if vcs == "git" {
+		return gitVCSURL(location, ref)
+	}
+	return vcs + "+" + location + "@" + ref
+}
+
+// normalizeGitRemote rewrites an scp-style remote such as
+// "git@github.com:org/repo.git" as "ssh://git@github.com/org/repo.git".
+// Other remotes are returned unchanged.
+func normalizeGitRemote(remote string) string {
+	if strings.Contains(remote, "://") {
+		return remote
+	}
+	if userHost, path, ok := strings.Cut(remote, ":"); ok && strings.Contains(userHost, "@") && !strings.Contains(userHost, "/") {
+		return "ssh://" + userHost + "/" + path
+	}
+	return remote
+}
+
+// gitRepoPath turns a repository URL such as
+// "https://github.com/apple/swift-nio.git" or "git@github.com:apple/swift-nio.git"
+// into "github.com/apple/swift-nio". Local paths return "".
+func gitRepoPath(location string) string {
+	u, err := url.Parse(normalizeGitRemote(location))
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	path := strings.TrimSuffix(strings.Trim(u.Path, "/"), ".git")
+	if path == "" {
+		return ""
+	}
+	return u.Host + "/" + path
+}
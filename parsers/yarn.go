@@ -5,6 +5,9 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"maps"
+	"net/url"
+	"slices"
 	"strings"
 
 	"github.com/git-pkgs/resolve"
@@ -32,30 +35,135 @@ func parseYarn(data []byte) ([]*resolve.Dep, error) {
 	return nil, fmt.Errorf("no tree entry found in yarn output")
 }
 
+// yarnTree is a node in Yarn Classic's list output. Top-level nodes are
+// "bold" when they are direct dependencies and have no color when they were
+// hoisted from deeper in the tree. Shadow nodes are "name@range" references to
+// a package that was hoisted to an ancestor and is listed there.
 type yarnTree struct {
 	Name     string     `json:"name"`
 	Children []yarnTree `json:"children"`
+	Color    string     `json:"color"`
+	Shadow   bool       `json:"shadow"`
 }
 
+// yarnNode is a physically installed package with its requirements resolved
+// to the nodes that satisfy them.
+type yarnNode struct {
+	name, version string
+	edges         []yarnEdge
+}
+
+type yarnEdge struct {
+	node       *yarnNode
+	constraint string
+}
+
+// walkYarnTrees reconstructs the logical requirement tree from yarn's hoisted
+// layout. Shadow entries are resolved the way Node does, to the nearest
+// ancestor level that installs the package, and hoisted packages are listed
+// under the first package that requires them. When the output marks direct
+// dependencies, hoisted top-level entries are not returned as direct unless
+// nothing requires them.
 func walkYarnTrees(trees []yarnTree) []*resolve.Dep {
-	var result []*resolve.Dep
+	_, topNodes := linkYarnLevel(trees, nil)
+
+	hasDirect := false
 	for _, tree := range trees {
+		if tree.Color == "bold" {
+			hasDirect = true
+			break
+		}
+	}
+
+	seen := make(map[*yarnNode]bool)
+	var buildDep func(node *yarnNode, constraint string) *resolve.Dep
+	buildDep = func(node *yarnNode, constraint string) *resolve.Dep {
+		dep := &resolve.Dep{
+			PURL:       resolve.MakePURL("npm", node.name, node.version),
+			Name:       node.name,
+			Version:    node.version,
+			Constraint: constraint,
+			Deps:       []*resolve.Dep{},
+		}
+		if seen[node] {
+			dep.Deduped = len(node.edges) > 0
+			return dep
+		}
+		seen[node] = true
+		for _, edge := range node.edges {
+			dep.Deps = append(dep.Deps, buildDep(edge.node, edge.constraint))
+		}
+		return dep
+	}
+
+	var result []*resolve.Dep
+	for i, node := range topNodes {
+		if node != nil && (!hasDirect || trees[i].Color == "bold") {
+			result = append(result, buildDep(node, ""))
+		}
+	}
+	// Hoisted packages nothing requires would otherwise be lost
+	for _, node := range topNodes {
+		if node != nil && !seen[node] {
+			result = append(result, buildDep(node, ""))
+		}
+	}
+	return result
+}
+
+// linkYarnLevel creates nodes for the non-shadow entries at one level of the
+// installed tree and resolves their requirements. scopes holds the packages
+// installed at each ancestor level, outermost first. The returned nodes line up
+// with trees and are nil for shadow entries.
+func linkYarnLevel(trees []yarnTree, scopes []map[string]*yarnNode) (map[string]*yarnNode, []*yarnNode) {
+	level := make(map[string]*yarnNode)
+	nodes := make([]*yarnNode, len(trees))
+	for i, tree := range trees {
+		if tree.Shadow {
+			continue
+		}
 		name, version := parseYarnName(tree.Name)
 		if name == "" {
 			continue
 		}
-		dep := &resolve.Dep{
-			PURL:    resolve.MakePURL("npm", name, version),
-			Name:    name,
-			Version: version,
-			Deps:    []*resolve.Dep{},
+		nodes[i] = &yarnNode{name: name, version: version}
+		level[name] = nodes[i]
+	}
+
+	scopes = append(scopes, level)
+	for i, tree := range trees {
+		node := nodes[i]
+		if node == nil {
+			continue
 		}
-		if len(tree.Children) > 0 {
-			dep.Deps = walkYarnTrees(tree.Children)
+		children, childNodes := linkYarnLevel(tree.Children, scopes)
+		for j, child := range tree.Children {
+			if !child.Shadow {
+				if childNodes[j] != nil {
+					node.edges = append(node.edges, yarnEdge{node: childNodes[j]})
+				}
+				continue
+			}
+			name, constraint := splitYarnLocator(child.Name)
+			target := resolveYarnShadow(name, append(scopes, children))
+			if target == nil {
+				target = &yarnNode{name: name}
+			}
+			node.edges = append(node.edges, yarnEdge{node: target, constraint: constraint})
 		}
-		result = append(result, dep)
 	}
-	return result
+	return level, nodes
+}
+
+// resolveYarnShadow finds the installed package a shadow entry refers to by
+// searching from the innermost level outward.
+func resolveYarnShadow(name string, scopes []map[string]*yarnNode) *yarnNode {
+	for i := len(scopes) - 1; i >= 0; i-- {
+		if node, ok := scopes[i][name]; ok {
+			return node
+		}
+	}
+	return nil
 }
 
 // parseYarnName splits "name@version" into name and version.
@@ -69,6 +177,252 @@ func parseYarnName(s string) (string, string) {
 	return s[:idx], s[idx+1:]
 }
 
+// yarnBerryEntry is one NDJSON line of `yarn info --all --recursive --json`
+// or `yarn why --json`. Its children are either an info object with a
+// Dependencies list, or (for yarn why) a map of locator to child entry.
+type yarnBerryEntry struct {
+	Value    string          `json:"value"`
+	Children json.RawMessage `json:"children"`
+}
+
+type yarnBerryDependency struct {
+	Descriptor string          `json:"descriptor"`
+	Locator    string          `json:"locator"`
+	Children   json.RawMessage `json:"children"`
+}
+
+type yarnBerryPackage struct {
+	locator string
+	version string
+	deps    []yarnBerryEdge
+}
+
+// yarnBerryEdge is a dependency of a package: the locator it resolved to and
+// the range it was requested with.
+type yarnBerryEdge struct {
+	locator    string
+	constraint string
+}
+
+// parseYarnBerry parses output from Yarn 2+ `yarn info --all --recursive --json`
+// or `yarn why --json`. Packages are identified by locators such as
+// "lodash@npm:4.17.21", "app@workspace:packages/app", "foo@portal:../foo" and
+// "resolve@patch:resolve@npm%3A1.22.8#...". Workspaces are the roots: with a
+// single workspace its dependencies are returned directly, otherwise each
+// workspace becomes a root like pnpm and npm workspaces. The range in each
+// dependency's descriptor ("lodash@npm:^4.17.21") is kept in Constraint.
+// Workspace and portal/link/file packages get no PURL.
+func parseYarnBerry(data []byte) ([]*resolve.Dep, error) {
+	pkgs := make(map[string]*yarnBerryPackage)
+	var order []string
+	add := func(locator string) *yarnBerryPackage {
+		locator = stripYarnVirtual(locator)
+		if pkg, ok := pkgs[locator]; ok {
+			return pkg
+		}
+		pkg := &yarnBerryPackage{locator: locator}
+		pkgs[locator] = pkg
+		order = append(order, locator)
+		return pkg
+	}
+
+	var collect func(pkg *yarnBerryPackage, children json.RawMessage)
+	collect = func(pkg *yarnBerryPackage, children json.RawMessage) {
+		var info struct {
+			Version      string                `json:"Version"`
+			Dependencies []yarnBerryDependency `json:"Dependencies"`
+		}
+		if json.Unmarshal(children, &info) == nil && (info.Version != "" || info.Dependencies != nil) {
+			if info.Version != "" {
+				pkg.version = info.Version
+			}
+			for _, d := range info.Dependencies {
+				pkg.deps = append(pkg.deps, yarnBerryEdge{add(d.Locator).locator, yarnBerryRange(d.Descriptor)})
+			}
+			return
+		}
+		var why map[string]yarnBerryDependency
+		if json.Unmarshal(children, &why) != nil {
+			return
+		}
+		// Map order is random; sort so children come out the same every run
+		for _, key := range slices.Sorted(maps.Keys(why)) {
+			d := why[key]
+			if d.Locator == "" {
+				continue
+			}
+			child := add(d.Locator)
+			pkg.deps = append(pkg.deps, yarnBerryEdge{child.locator, yarnBerryRange(d.Descriptor)})
+			collect(child, d.Children)
+		}
+	}
+
+	var entries []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024) //nolint:mnd // info lines can be long
+	for scanner.Scan() {
+		var entry yarnBerryEntry
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil || entry.Value == "" {
+			continue
+		}
+		pkg := add(entry.Value)
+		entries = append(entries, pkg.locator)
+		collect(pkg, entry.Children)
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no package entries found in yarn output")
+	}
+
+	var workspaces []string
+	for _, locator := range order {
+		if _, ref := splitYarnLocator(locator); strings.HasPrefix(ref, "workspace:") {
+			workspaces = append(workspaces, locator)
+		}
+	}
+
+	seen := make(map[string]bool)
+	var buildDep func(edge yarnBerryEdge) *resolve.Dep
+	buildDep = func(edge yarnBerryEdge) *resolve.Dep {
+		pkg := pkgs[edge.locator]
+		dep := newYarnBerryDep(pkg)
+		dep.Constraint = edge.constraint
+		if dep.Source == "workspace" || seen[edge.locator] {
+			dep.Deduped = len(pkg.deps) > 0
+			return dep
+		}
+		seen[edge.locator] = true
+		for _, child := range pkg.deps {
+			dep.Deps = append(dep.Deps, buildDep(child))
+		}
+		return dep
+	}
+	expand := func(locator string) []*resolve.Dep {
+		deps := []*resolve.Dep{}
+		for _, child := range pkgs[locator].deps {
+			deps = append(deps, buildDep(child))
+		}
+		return deps
+	}
+
+	switch len(workspaces) {
+	case 0:
+		// Without workspaces, the listed entries are the roots
+		var deps []*resolve.Dep
+		for _, locator := range entries {
+			deps = append(deps, buildDep(yarnBerryEdge{locator: locator}))
+		}
+		return deps, nil
+	case 1:
+		return expand(workspaces[0]), nil
+	}
+
+	var roots []*resolve.Dep
+	for _, locator := range workspaces {
+		root := newYarnBerryDep(pkgs[locator])
+		root.Deps = expand(locator)
+		roots = append(roots, root)
+	}
+	return roots, nil
+}
+
+// newYarnBerryDep builds a Dep from a locator, mapping Yarn's protocols to
+// the underlying package and source. Workspace and local path packages aren't
+// in the registry, so they get no PURL.
+func newYarnBerryDep(pkg *yarnBerryPackage) *resolve.Dep {
+	name, ref := splitYarnLocator(pkg.locator)
+	dep := &resolve.Dep{Name: name, Version: pkg.version, Deps: []*resolve.Dep{}}
+	var qualifiers map[string]string
+
+	protocol, rest, _ := strings.Cut(ref, ":")
+	switch protocol {
+	case "npm":
+		// Aliases resolve to another package: "alias@npm:real@1.0.0"
+		if idx := strings.LastIndex(rest, "@"); idx > 0 {
+			dep.Name, rest = rest[:idx], rest[idx+1:]
+		}
+		dep.Version = rest
+	case "patch":
+		// The patched package's own locator is URL-encoded before the "#"
+		inner, _, _ := strings.Cut(rest, "#")
+		if decoded, err := url.PathUnescape(inner); err == nil {
+			inner = decoded
+		}
+		base := newYarnBerryDep(&yarnBerryPackage{locator: inner, version: pkg.version})
+		dep.Name, dep.Version = base.Name, base.Version
+		dep.Source = "patch"
+	case "workspace":
+		dep.Source = "workspace"
+		dep.Location = rest
+	case "portal", "link", "file":
+		dep.Source = "path"
+		dep.Location, _, _ = strings.Cut(rest, "::")
+	case "https", "http":
+		dep.Source = "url"
+		dep.Location = ref
+		qualifiers = map[string]string{"download_url": ref}
+	}
+
+	// Git dependencies resolve to "<repo>#commit=<sha>" under any protocol
+	if repo, commit, ok := strings.Cut(ref, "#commit="); ok {
+		dep.Source = "git"
+		dep.Location = repo
+		qualifiers = map[string]string{"vcs_url": gitVCSURL(repo, commit)}
+	}
+
+	if dep.Source != "workspace" && dep.Source != "path" {
+		dep.PURL = resolve.MakePURLWithQualifiers("npm", dep.Name, dep.Version, qualifiers)
+	}
+	return dep
+}
+
+// yarnBerryRange returns the npm range a descriptor asks for, such as
+// "^4.17.21" from "lodash@npm:^4.17.21" or "strip-ansi-cjs@npm:strip-ansi@^6.0.1",
+// looking inside patch: descriptors. Other protocols name a single source
+// rather than a range and return "".
+func yarnBerryRange(descriptor string) string {
+	_, ref := splitYarnLocator(descriptor)
+	protocol, rest, _ := strings.Cut(ref, ":")
+	switch protocol {
+	case "npm":
+		if idx := strings.LastIndex(rest, "@"); idx > 0 {
+			rest = rest[idx+1:]
+		}
+		return rest
+	case "patch":
+		inner, _, _ := strings.Cut(rest, "#")
+		if decoded, err := url.PathUnescape(inner); err == nil {
+			inner = decoded
+		}
+		return yarnBerryRange(inner)
+	}
+	return ""
+}
+
+// splitYarnLocator splits "name@reference" or "@scope/name@reference".
+func splitYarnLocator(locator string) (string, string) {
+	if len(locator) < 2 { //nolint:mnd // need at least one name character
+		return locator, ""
+	}
+	idx := strings.Index(locator[1:], "@")
+	if idx < 0 {
+		return locator, ""
+	}
+	return locator[:idx+1], locator[idx+2:]
+}
+
+// stripYarnVirtual removes the "virtual:<hash>#" prefix Yarn adds to packages
+// with peer dependencies, so virtual instances share their package's locator.
+func stripYarnVirtual(locator string) string {
+	name, ref := splitYarnLocator(locator)
+	if rest, ok := strings.CutPrefix(ref, "virtual:"); ok {
+		if _, real, found := strings.Cut(rest, "#"); found {
+			return name + "@" + real
+		}
+	}
+	return locator
+}
+
 func init() {
 	resolve.Register("yarn", "npm", parseYarn)
+	resolve.Register("yarn-berry", "npm", parseYarnBerry)
 }
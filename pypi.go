@@ -0,0 +1,101 @@
+package resolve
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// pypiNameSepRe matches runs of separators that PEP 503 treats as equivalent.
+var pypiNameSepRe = regexp.MustCompile(`[-_.]+`)
+
+// NormalizePyPIName returns the PEP 503 canonical form of a Python package name,
+// so "Flask", "zope.interface" and "Zope_Interface" compare as "flask" and
+// "zope-interface".
+func NormalizePyPIName(name string) string {
+	return strings.ToLower(pypiNameSepRe.ReplaceAllString(name, "-"))
+}
+
+// pep440Re is the PEP 440 version grammar, accepting the alternate spellings
+// that pip and friends normalize away.
+var pep440Re = regexp.MustCompile(`(?i)^\s*v?` +
+	`(?:(?P<epoch>[0-9]+)!)?` +
+	`(?P<release>[0-9]+(?:\.[0-9]+)*)` +
+	`(?:[-_.]?(?P<pre_l>alpha|beta|preview|pre|rc|a|b|c)[-_.]?(?P<pre_n>[0-9]+)?)?` +
+	`(?:-(?P<post_n1>[0-9]+)|[-_.]?(?P<post_l>post|rev|r)[-_.]?(?P<post_n2>[0-9]+)?)?` +
+	`(?:[-_.]?(?P<dev_l>dev)[-_.]?(?P<dev_n>[0-9]+)?)?` +
+	`(?:\+(?P<local>[a-z0-9]+(?:[-_.][a-z0-9]+)*))?\s*$`)
+
+// pep440PreLabels maps pre-release spellings to their canonical labels.
+var pep440PreLabels = map[string]string{
+	"a": "a", "alpha": "a",
+	"b": "b", "beta": "b",
+	"c": "rc", "rc": "rc", "pre": "rc", "preview": "rc",
+}
+
+// CanonicalPyPIVersion returns a PEP 440 version in canonical form for
+// comparison: alternate spellings are normalized, leading zeros and trailing
+// ".0" release segments are dropped, and local labels use "." separators.
+// "1.0", "1.0.0" and "v1" all become "1"; "1.0+Ubuntu-1" becomes "1+ubuntu.1".
+// Strings that are not valid PEP 440 versions are returned unchanged.
+func CanonicalPyPIVersion(version string) string {
+	m := pep440Re.FindStringSubmatch(version)
+	if m == nil {
+		return version
+	}
+	group := func(name string) string {
+		return m[pep440Re.SubexpIndex(name)]
+	}
+
+	var b strings.Builder
+	if epoch := trimLeadingZeros(group("epoch")); epoch != "" && epoch != "0" {
+		b.WriteString(epoch + "!")
+	}
+
+	release := strings.Split(group("release"), ".")
+	for i := range release {
+		release[i] = trimLeadingZeros(release[i])
+	}
+	for len(release) > 1 && release[len(release)-1] == "0" {
+		release = release[:len(release)-1]
+	}
+	b.WriteString(strings.Join(release, "."))
+
+	if label := group("pre_l"); label != "" {
+		b.WriteString(pep440PreLabels[strings.ToLower(label)] + numberOrZero(group("pre_n")))
+	}
+	if group("post_l") != "" || group("post_n1") != "" {
+		b.WriteString(".post" + numberOrZero(group("post_n1")+group("post_n2")))
+	}
+	if group("dev_l") != "" {
+		b.WriteString(".dev" + numberOrZero(group("dev_n")))
+	}
+	if local := group("local"); local != "" {
+		parts := pypiNameSepRe.Split(strings.ToLower(local), -1)
+		for i, part := range parts {
+			if _, err := strconv.Atoi(part); err == nil {
+				parts[i] = trimLeadingZeros(part)
+			}
+		}
+		b.WriteString("+" + strings.Join(parts, "."))
+	}
+	return b.String()
+}
+
+func trimLeadingZeros(s string) string {
+	if s == "" {
+		return ""
+	}
+	s = strings.TrimLeft(s, "0")
+	if s == "" {
+		return "0"
+	}
+	return s
+}
+
+func numberOrZero(s string) string {
+	if s == "" {
+		return "0"
+	}
+	return trimLeadingZeros(s)
+}
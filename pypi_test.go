@@ -0,0 +1,52 @@
+package resolve
+
+import (
+	"testing"
+)
+
+func TestNormalizePyPIName(t *testing.T) {
+	tests := []struct {
+		name, want string
+	}{
+		{"Flask", "flask"},
+		{"zope.interface", "zope-interface"},
+		{"Zope_Interface", "zope-interface"},
+		{"foo__bar-.baz", "foo-bar-baz"},
+	}
+	for _, tt := range tests {
+		if got := NormalizePyPIName(tt.name); got != tt.want {
+			t.Errorf("NormalizePyPIName(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCanonicalPyPIVersion(t *testing.T) {
+	tests := []struct {
+		version, want string
+	}{
+		{"1.0", "1"},
+		{"1.0.0", "1"},
+		{"v1", "1"},
+		{"2024.01.0", "2024.1"},
+		{"1.0.0+Ubuntu-1", "1+ubuntu.1"},
+		{"2.1RC1", "2.1rc1"},
+		{"1.0-preview.2", "1rc2"},
+		{"1.0.0a", "1a0"},
+		{"1.0-1", "1.post1"},
+		{"1.0_b2.post3.dev4", "1b2.post3.dev4"},
+		{"1!1.0.dev1", "1!1.dev1"},
+		{"0!1.2", "1.2"},
+		{"not a version", "not a version"},
+	}
+	for _, tt := range tests {
+		if got := CanonicalPyPIVersion(tt.version); got != tt.want {
+			t.Errorf("CanonicalPyPIVersion(%q) = %q, want %q", tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestMakePURLNormalizesPyPINames(t *testing.T) {
+	if got := MakePURL("pypi", "Zope_Interface", "6.1"); got != "pkg:pypi/zope-interface@6.1" {
+		t.Errorf("MakePURL = %q, want pkg:pypi/zope-interface@6.1", got)
+	}
+}
@@ -3,6 +3,7 @@ package resolve
 import (
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/git-pkgs/purl"
 )
@@ -12,10 +13,29 @@ var ErrUnsupportedManager = errors.New("unsupported manager")
 
 // Dep is a single resolved dependency.
 type Dep struct {
-	PURL    string // pkg:npm/%40scope/name@1.0.0
-	Name    string // ecosystem-native name (@scope/name)
-	Version string // resolved version (1.0.0)
-	Deps    []*Dep // transitive deps; nil for flat-list managers
+	PURL       string // pkg:npm/%40scope/name@1.0.0
+	Name       string // ecosystem-native name (@scope/name)
+	Version    string // resolved version (1.0.0)
+	Constraint string // version range requested by the parent (>=2.0,<3), when shown
+	Scope      string // "dev", "build", etc.; empty for normal dependencies
+	Extra      string // optional feature of the parent that pulls this dep in (Python extras)
+	Target     string // target framework or platform the dep was resolved for (net8.0)
+	Indirect   bool   // listed as transitive by a manager that doesn't report edges
+	Deduped    bool   // subtree omitted because the package is expanded elsewhere
+	Source     string // "workspace", "path", "git", etc.; empty for the default registry
+	Location   string // local path or URL for non-registry sources
+	License    string // license the manager reported (BSD-3-Clause), when shown
+	Deps       []*Dep // transitive deps; nil for flat-list managers
+
+	// Diagnostics holds problems the manager reported for this dependency.
+	Diagnostics []Diagnostic
+}
+
+// Diagnostic is a problem a package manager reported about a dependency,
+// such as a missing download or a version that doesn't match the request.
+type Diagnostic struct {
+	Kind    string // "missing", "wrong-version", etc.
+	Message string // the manager's own wording
 }
 
 // Result is the parsed dependency graph for one manager invocation.
@@ -59,6 +79,57 @@ func Parse(manager string, output []byte) (*Result, error) {
 }
 
 // MakePURL constructs a PURL string for a dependency.
+// PyPI names are normalized per PEP 503 and GitHub names lowercased, as the
+// purl-spec requires. For jsr, generic, swift, hex and github, everything
+// before the last "/" in name becomes the namespace, and CocoaPods subspecs
+// ("Firebase/Analytics") become the subpath.
 func MakePURL(ecosystem, name, version string) string {
-	return purl.MakePURL(ecosystem, name, version).String()
+	return makePURL(ecosystem, name, version).String()
+}
+
+// MakePURLWithQualifiers is like MakePURL but adds qualifiers such as
+// repository_url or vcs_url. Empty values are omitted.
+func MakePURLWithQualifiers(ecosystem, name, version string, qualifiers map[string]string) string {
+	p := makePURL(ecosystem, name, version)
+	q := make(map[string]string, len(qualifiers))
+	for k, v := range qualifiers {
+		if v != "" {
+			q[k] = v
+		}
+	}
+	withQualifiers := purl.New(p.Type, p.Namespace, p.Name, p.Version, q)
+	withQualifiers.Subpath = p.Subpath
+	return withQualifiers.String()
+}
+
+// pathNamespaceTypes are PURL types whose namespace is a path prefix of the
+// name, which purl.MakePURL leaves unsplit.
+var pathNamespaceTypes = map[string]bool{
+	"jsr":     true,
+	"generic": true,
+	"swift":   true,
+	"hex":     true,
+	"github":  true,
+}
+
+func makePURL(ecosystem, name, version string) *purl.PURL {
+	switch ecosystem {
+	case "pypi":
+		name = NormalizePyPIName(name)
+	case "github":
+		name = strings.ToLower(name)
+	}
+	p := purl.MakePURL(ecosystem, name, version)
+	if pathNamespaceTypes[p.Type] && p.Namespace == "" {
+		if idx := strings.LastIndex(name, "/"); idx > 0 {
+			p = purl.New(p.Type, name[:idx], name[idx+1:], p.Version, nil)
+		}
+	}
+	if p.Type == "cocoapods" {
+		if pod, subspec, ok := strings.Cut(name, "/"); ok {
+			p = purl.New(p.Type, "", pod, p.Version, nil)
+			p.Subpath = subspec
+		}
+	}
+	return p
 }
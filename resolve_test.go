@@ -4,6 +4,7 @@ import (
 	"errors"
 	"os"
 	"path/filepath"
+	"slices"
 	"strings"
 	"testing"
 
@@ -51,6 +52,17 @@ func TestParseReturnsManagerAndEcosystem(t *testing.T) {
 	}
 }
 
+func TestMakePURLWithQualifiers(t *testing.T) {
+	got := resolve.MakePURLWithQualifiers("npm", "@acme/ui", "1.0.0", map[string]string{
+		"vcs_url":        "git+https://github.com/acme/ui.git@abc123",
+		"repository_url": "",
+	})
+	want := "pkg:npm/%40acme/ui@1.0.0?vcs_url=git%2Bhttps:%2F%2Fgithub.com%2Facme%2Fui.git%40abc123"
+	if got != want {
+		t.Errorf("MakePURLWithQualifiers = %q, want %q", got, want)
+	}
+}
+
 func TestNPM(t *testing.T) {
 	result, err := resolve.Parse("npm", loadFixture(t, "npm.json"))
 	if err != nil {
@@ -114,6 +126,79 @@ func TestNPMLongOutput(t *testing.T) {
 	}
 }
 
+func TestNPMTarballWithoutIntegrity(t *testing.T) {
+	npmOutput := `{"name":"app","dependencies":{"foo":{"version":"1.0.0","resolved":"https://example.com/foo-1.0.0.tgz"}}}`
+	pnpmOutput := `[{"name":"app","dependencies":{"foo":{"version":"1.0.0","resolved":"https://example.com/foo-1.0.0.tgz"}}}]`
+	want := "pkg:npm/foo@1.0.0?download_url=https:%2F%2Fexample.com%2Ffoo-1.0.0.tgz"
+	for manager, output := range map[string]string{"npm": npmOutput, "pnpm": pnpmOutput} {
+		result, err := resolve.Parse(manager, []byte(output))
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", manager, err)
+		}
+		foo := findDep(result.Direct, "foo")
+		if foo == nil || foo.Source != "url" || foo.PURL != want {
+			t.Errorf("%s: foo = %+v, want PURL %q", manager, foo, want)
+		}
+	}
+}
+
+func TestNPMWorkspaces(t *testing.T) {
+	result, err := resolve.Parse("npm", loadFixture(t, "npm-workspace.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The project root plus one root per workspace
+	checkTreeResult(t, result, "npm", 3, []depCheck{
+		{"acme", "1.0.0", 2},
+		{"@acme/ui", "0.2.0", 1},
+		{"@acme/web", "0.1.0", 3},
+	})
+
+	ui := findDep(result.Direct, "@acme/ui")
+	if ui.Source != "workspace" || ui.Location != "/home/user/acme/packages/ui" {
+		t.Errorf("@acme/ui source = %q at %q, want workspace", ui.Source, ui.Location)
+	}
+	if ui.PURL != "" {
+		t.Errorf("@acme/ui PURL = %q, want none for a workspace package", ui.PURL)
+	}
+	if react := findDep(ui.Deps, "react"); react == nil || react.PURL != "pkg:npm/react@18.2.0" {
+		t.Errorf("react under @acme/ui = %+v, want plain registry PURL", react)
+	}
+
+	web := findDep(result.Direct, "@acme/web")
+	if link := findDep(web.Deps, "@acme/ui"); link == nil || link.Source != "workspace" || !link.Deduped {
+		t.Errorf("@acme/ui under @acme/web = %+v, want workspace edge", link)
+	}
+	leftPad := findDep(web.Deps, "left-pad")
+	if leftPad == nil || leftPad.Source != "git" {
+		t.Fatalf("left-pad = %+v, want git source", leftPad)
+	}
+	if !strings.Contains(leftPad.PURL, "vcs_url=git%2Bssh:%2F%2Fgit%40github.com%2Fstevemao%2Fleft-pad.git%405ba2d0b") {
+		t.Errorf("left-pad PURL = %q, want vcs_url qualifier", leftPad.PURL)
+	}
+	internal := findDep(web.Deps, "internal-lib")
+	if internal == nil || !strings.HasSuffix(internal.PURL, "?repository_url=https:%2F%2Fnpm.internal.example.com") {
+		t.Errorf("internal-lib = %+v, want repository_url qualifier", internal)
+	}
+
+	acme := findDep(result.Direct, "acme")
+	shared := findDep(acme.Deps, "shared-config")
+	if shared == nil || shared.Source != "path" || shared.Location != "/home/user/shared-config" {
+		t.Errorf("shared-config = %+v, want local path dep", shared)
+	}
+	if shared != nil && shared.PURL != "" {
+		t.Errorf("shared-config PURL = %q, want none for a file: dep", shared.PURL)
+	}
+	vendored := findDep(acme.Deps, "vendored")
+	if vendored == nil || vendored.Source != "url" {
+		t.Fatalf("vendored = %+v, want url source", vendored)
+	}
+	if !strings.Contains(vendored.PURL, "download_url=https:%2F%2Fexample.com%2Fdownloads%2Fvendored-0.5.0.tgz") ||
+		!strings.Contains(vendored.PURL, "checksum=sha512:ff722331") {
+		t.Errorf("vendored PURL = %q, want download_url and checksum qualifiers", vendored.PURL)
+	}
+}
+
 func TestPNPM(t *testing.T) {
 	result, err := resolve.Parse("pnpm", loadFixture(t, "pnpm.json"))
 	if err != nil {
@@ -135,6 +220,53 @@ func TestPNPM(t *testing.T) {
 	}
 }
 
+func TestPNPMWorkspaces(t *testing.T) {
+	result, err := resolve.Parse("pnpm", loadFixture(t, "pnpm-workspace.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// One root per workspace package
+	checkTreeResult(t, result, "npm", 3, []depCheck{
+		{"acme-monorepo", "1.0.0", 1},
+		{"@acme/ui", "0.2.0", 2},
+		{"@acme/web", "0.1.0", 4},
+	})
+
+	ui := findDep(result.Direct, "@acme/ui")
+	if ui.Source != "workspace" || ui.Location != "/home/user/acme/packages/ui" {
+		t.Errorf("@acme/ui source = %q at %q, want workspace at /home/user/acme/packages/ui", ui.Source, ui.Location)
+	}
+	if fsevents := findDep(ui.Deps, "fsevents"); fsevents == nil || fsevents.Scope != "optional" {
+		t.Errorf("fsevents = %+v, want optional dep", fsevents)
+	}
+
+	web := findDep(result.Direct, "@acme/web")
+	link := findDep(web.Deps, "@acme/ui")
+	if link == nil {
+		t.Fatal("missing @acme/ui under @acme/web")
+	}
+	if link.Version != "0.2.0" || link.Source != "workspace" {
+		t.Errorf("@acme/ui link = %q (source %q), want 0.2.0 (workspace)", link.Version, link.Source)
+	}
+	if link.PURL != "" || ui.PURL != "" {
+		t.Errorf("@acme/ui PURLs = %q and %q, want none for a workspace package", ui.PURL, link.PURL)
+	}
+
+	utils := findDep(web.Deps, "local-utils")
+	if utils == nil {
+		t.Fatal("missing local-utils under @acme/web")
+	}
+	if utils.Source != "path" || utils.Version != "" || utils.Location != "/home/user/acme/vendor/local-utils" {
+		t.Errorf("local-utils = %+v, want unversioned path dep at /home/user/acme/vendor/local-utils", utils)
+	}
+	if utils.PURL != "" {
+		t.Errorf("local-utils PURL = %q, want none for a link: path", utils.PURL)
+	}
+	if vitest := findDep(web.Deps, "vitest"); vitest == nil || vitest.Scope != "dev" {
+		t.Errorf("vitest = %+v, want dev dep", vitest)
+	}
+}
+
 func TestYarn(t *testing.T) {
 	result, err := resolve.Parse("yarn", loadFixture(t, "yarn.json"))
 	if err != nil {
@@ -164,6 +296,128 @@ func TestYarn(t *testing.T) {
 	}
 }
 
+func TestYarnHoisted(t *testing.T) {
+	result, err := resolve.Parse("yarn", loadFixture(t, "yarn-hoisted.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// chalk, react-dom and react are bold; fsevents is hoisted but nothing requires it
+	checkTreeResult(t, result, "npm", 5, []depCheck{
+		{"chalk", "2.4.2", 3},
+		{"react", "18.2.0", 1},
+		{"react-dom", "18.2.0", 2},
+		{"fsevents", "2.3.3", 0},
+		{"supports-color", "7.2.0", 0},
+	})
+
+	chalk := findDep(result.Direct, "chalk")
+	styles := findDep(chalk.Deps, "ansi-styles")
+	if styles == nil || styles.Version != "3.2.1" || styles.Constraint != "^3.2.1" {
+		t.Fatalf("ansi-styles under chalk = %+v, want hoisted 3.2.1 with constraint ^3.2.1", styles)
+	}
+	if convert := findDep(styles.Deps, "color-convert"); convert == nil || len(convert.Deps) != 1 {
+		t.Errorf("color-convert under ansi-styles = %+v, want 1 dep", convert)
+	}
+	supports := findDep(chalk.Deps, "supports-color")
+	if supports == nil || supports.Version != "5.5.0" || supports.Constraint != "" {
+		t.Fatalf("supports-color under chalk = %+v, want nested 5.5.0", supports)
+	}
+	if flag := findDep(supports.Deps, "has-flag"); flag == nil || flag.Version != "3.0.0" {
+		t.Errorf("has-flag under supports-color = %+v, want 3.0.0", flag)
+	}
+
+	// loose-envify is expanded under react-dom, the first requester, and
+	// referenced from react and scheduler
+	reactDOM := findDep(result.Direct, "react-dom")
+	envify := findDep(reactDOM.Deps, "loose-envify")
+	if envify == nil || envify.Deduped || len(envify.Deps) != 1 {
+		t.Fatalf("loose-envify under react-dom = %+v, want expanded", envify)
+	}
+	if tokens := envify.Deps[0]; tokens.Version != "4.0.0" || tokens.Constraint != "^3.0.0 || ^4.0.0" {
+		t.Errorf("js-tokens = %+v, want 4.0.0 with constraint ^3.0.0 || ^4.0.0", tokens)
+	}
+	react := findDep(result.Direct, "react")
+	if ref := react.Deps[0]; ref.Name != "loose-envify" || !ref.Deduped || len(ref.Deps) != 0 {
+		t.Errorf("loose-envify under react = %+v, want deduped reference", ref)
+	}
+
+	for _, d := range result.Direct {
+		if d.Name == "loose-envify" || d.Name == "js-tokens" {
+			t.Errorf("hoisted %s should not be direct", d.Name)
+		}
+	}
+}
+
+func TestYarnBerry(t *testing.T) {
+	result, err := resolve.Parse("yarn-berry", loadFixture(t, "yarn-berry.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	checkTreeResult(t, result, "npm", 3, []depCheck{
+		{"@acme/web", "0.1.0", 3},
+		{"@acme/ui", "0.2.0", 2},
+		{"acme", "0.0.0-use.local", 3},
+	})
+
+	web := findDep(result.Direct, "@acme/web")
+	if web.PURL != "" {
+		t.Errorf("@acme/web PURL = %q, want none for a workspace", web.PURL)
+	}
+	if ui := findDep(web.Deps, "@acme/ui"); ui == nil || ui.Source != "workspace" || ui.Location != "packages/ui" || ui.PURL != "" {
+		t.Errorf("@acme/ui under @acme/web = %+v, want workspace edge without PURL", ui)
+	}
+	if lodash := findDep(web.Deps, "lodash"); lodash == nil || lodash.Constraint != "^4.17.21" {
+		t.Errorf("lodash = %+v, want constraint ^4.17.21", lodash)
+	}
+	reactDOM := findDep(web.Deps, "react-dom")
+	if reactDOM == nil || reactDOM.PURL != "pkg:npm/react-dom@18.2.0" || len(reactDOM.Deps) != 2 {
+		t.Errorf("react-dom = %+v, want virtual locator resolved to pkg:npm/react-dom@18.2.0 with 2 deps", reactDOM)
+	}
+
+	ui := findDep(result.Direct, "@acme/ui")
+	shared := findDep(ui.Deps, "shared")
+	if shared == nil || shared.Source != "path" || shared.Location != "../shared" || shared.PURL != "" || len(shared.Deps) != 1 {
+		t.Errorf("shared = %+v, want portal path dep with 1 dep and no PURL", shared)
+	}
+
+	acme := findDep(result.Direct, "acme")
+	if ts := findDep(acme.Deps, "typescript"); ts == nil || ts.PURL != "pkg:npm/typescript@5.3.3" || ts.Source != "patch" || ts.Constraint != "^5.3.3" {
+		t.Errorf("typescript = %+v, want patched pkg:npm/typescript@5.3.3", ts)
+	}
+	if alias := findDep(acme.Deps, "strip-ansi"); alias == nil || alias.PURL != "pkg:npm/strip-ansi@6.0.1" || alias.Constraint != "^6.0.1" {
+		t.Errorf("strip-ansi alias = %+v, want pkg:npm/strip-ansi@6.0.1", alias)
+	}
+	leftPad := findDep(acme.Deps, "left-pad")
+	if leftPad == nil || leftPad.Source != "git" || leftPad.Version != "1.3.0" {
+		t.Fatalf("left-pad = %+v, want git dep at 1.3.0", leftPad)
+	}
+	if leftPad.PURL != "pkg:npm/left-pad@1.3.0?vcs_url=git%2Bhttps:%2F%2Fgithub.com%2Fstevemao%2Fleft-pad.git%405ba2d0b" {
+		t.Errorf("left-pad PURL = %q, want git+ vcs_url qualifier", leftPad.PURL)
+	}
+}
+
+func TestYarnBerryWhy(t *testing.T) {
+	output := `{"value":"my-app@workspace:.","children":{"lodash@npm:4.17.21":{"descriptor":"lodash@npm:^4.17.21","locator":"lodash@npm:4.17.21"},"chalk@npm:5.3.0":{"descriptor":"chalk@npm:^5.3.0","locator":"chalk@npm:5.3.0"},"zod@patch:zod@npm%3A3.22.4+fix#./patches/zod.patch::version=3.22.4+fix&hash=1a2b3c":{"descriptor":"zod@patch:zod@npm%3A^3.22.4#./patches/zod.patch","locator":"zod@patch:zod@npm%3A3.22.4+fix#./patches/zod.patch::version=3.22.4+fix&hash=1a2b3c"}}}
+{"value":"express@npm:4.18.2","children":{"debug@npm:2.6.9":{"descriptor":"debug@npm:2.6.9","locator":"debug@npm:2.6.9"}}}
+`
+	result, err := resolve.Parse("yarn-berry", []byte(output))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	checkTreeResult(t, result, "npm", 3, []depCheck{
+		{"lodash", "4.17.21", 0},
+		{"zod", "3.22.4+fix", 0},
+	})
+	// yarn why children are a JSON object; they come back in locator order
+	var names []string
+	for _, d := range result.Direct {
+		names = append(names, d.Name)
+	}
+	if want := []string{"chalk", "lodash", "zod"}; !slices.Equal(names, want) {
+		t.Errorf("direct deps = %v, want %v", names, want)
+	}
+}
+
 func TestBun(t *testing.T) {
 	result, err := resolve.Parse("bun", loadFixture(t, "bun.txt"))
 	if err != nil {
@@ -208,6 +462,62 @@ func TestCargo(t *testing.T) {
 	}
 }
 
+func TestCargoTree(t *testing.T) {
+	result, err := resolve.Parse("cargo-tree", loadFixture(t, "cargo-tree.txt"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	checkTreeResult(t, result, "cargo", 4, []depCheck{
+		{"serde", "1.0.193", 1},
+		{"tokio", "1.35.0", 3},
+		{"cc", "1.0.83", 0},
+		{"tempfile", "3.8.1", 2},
+	})
+
+	serde := findDep(result.Direct, "serde")
+	derive := serde.Deps[0]
+	if derive.Version != "1.0.193" || len(derive.Deps) != 3 {
+		t.Errorf("serde_derive = %q with %d deps, want 1.0.193 with 3", derive.Version, len(derive.Deps))
+	}
+	quote := findDep(derive.Deps, "quote")
+	if quote == nil || len(quote.Deps) != 1 {
+		t.Fatal("quote should have 1 dep under serde_derive")
+	}
+	if pm := quote.Deps[0]; len(pm.Deps) != 0 || !pm.Deduped {
+		t.Errorf("deduplicated proc-macro2 = %+v, want Deduped with no children", pm)
+	}
+
+	if scope := findDep(result.Direct, "serde").Scope; scope != "" {
+		t.Errorf("serde scope = %q, want empty", scope)
+	}
+	if scope := findDep(result.Direct, "cc").Scope; scope != "build" {
+		t.Errorf("cc scope = %q, want build", scope)
+	}
+	tempfile := findDep(result.Direct, "tempfile")
+	if tempfile.Scope != "dev" {
+		t.Errorf("tempfile scope = %q, want dev", tempfile.Scope)
+	}
+	if cfgIf := findDep(tempfile.Deps, "cfg-if"); cfgIf == nil || cfgIf.Scope != "dev" {
+		t.Errorf("cfg-if under tempfile = %+v, want dev scope", cfgIf)
+	}
+	autocfg := findDep(findDep(result.Direct, "tokio").Deps, "autocfg")
+	if autocfg == nil || autocfg.Scope != "build" {
+		t.Errorf("autocfg under tokio should be a build dep, got %+v", autocfg)
+	}
+}
+
+func TestCargoTreeDepthPrefix(t *testing.T) {
+	output := "0my-project v0.1.0 (/home/user/project)\n1serde v1.0.193\n2serde_derive v1.0.193 (proc-macro)\n1tokio v1.35.0\n"
+	result, err := resolve.Parse("cargo-tree", []byte(output))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	checkTreeResult(t, result, "cargo", 2, []depCheck{
+		{"serde", "1.0.193", 1},
+		{"tokio", "1.35.0", 0},
+	})
+}
+
 type depCheck struct {
 	name         string
 	version      string
@@ -252,20 +562,102 @@ func TestPip(t *testing.T) {
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	if len(result.Direct) != 3 {
-		t.Fatalf("expected 3 deps, got %d", len(result.Direct))
-	}
+	// Only requested distributions are direct
+	checkTreeResult(t, result, "pypi", 3, []depCheck{
+		{"requests", "2.31.0", 4},
+		{"click", "8.1.7", 0}, // colorama is Windows-only
+		{"Jinja2", "3.1.2", 1},
+	})
 	requests := findDep(result.Direct, "requests")
-	if requests == nil {
-		t.Fatal("missing requests")
-	}
-	// Flat list: Deps should be nil
-	if requests.Deps != nil {
-		t.Error("pip deps should have nil Deps (flat list)")
-	}
 	if !strings.Contains(requests.PURL, "pkg:pypi/requests@2.31.0") {
 		t.Errorf("requests PURL = %q", requests.PURL)
 	}
+	if findDep(requests.Deps, "urllib3") == nil {
+		t.Error("missing urllib3 under requests")
+	}
+	// Requirement "markupsafe" matches installed "MarkupSafe"
+	jinja := findDep(result.Direct, "Jinja2")
+	if findDep(jinja.Deps, "MarkupSafe") == nil {
+		t.Error("missing MarkupSafe under Jinja2")
+	}
+	// PURL names are PEP 503 normalized; Name keeps the original spelling
+	if jinja.PURL != "pkg:pypi/jinja2@3.1.2" {
+		t.Errorf("Jinja2 PURL = %q, want pkg:pypi/jinja2@3.1.2", jinja.PURL)
+	}
+}
+
+func TestPipWithoutRequested(t *testing.T) {
+	output := `{"installed": [
+		{"metadata": {"name": "requests", "version": "2.31.0", "requires_dist": ["certifi>=2017.4.17", "PySocks; extra == 'socks'"]}},
+		{"metadata": {"name": "certifi", "version": "2024.12.14"}},
+		{"metadata": {"name": "PySocks", "version": "1.7.1"}}
+	]}`
+	result, err := resolve.Parse("pip", []byte(output))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Without "requested", anything not required by another package is direct
+	checkTreeResult(t, result, "pypi", 2, []depCheck{
+		{"requests", "2.31.0", 1},
+		{"PySocks", "1.7.1", 0},
+	})
+}
+
+func TestPipCycleWithoutRequested(t *testing.T) {
+	output := `{"installed": [
+		{"metadata": {"name": "six", "version": "1.16.0"}},
+		{"metadata": {"name": "sphinx", "version": "7.2.6", "requires_dist": ["sphinxcontrib-applehelp"]}},
+		{"metadata": {"name": "sphinxcontrib-applehelp", "version": "1.0.8", "requires_dist": ["sphinx>=5"]}}
+	]}`
+	result, err := resolve.Parse("pip", []byte(output))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// sphinx and applehelp require each other, so neither is a root by itself
+	checkTreeResult(t, result, "pypi", 2, []depCheck{
+		{"six", "1.16.0", 0},
+		{"sphinx", "7.2.6", 1},
+	})
+	applehelp := result.Direct[1].Deps[0]
+	if applehelp.Name != "sphinxcontrib-applehelp" || len(applehelp.Deps) != 1 || !applehelp.Deps[0].Deduped {
+		t.Errorf("applehelp = %+v, want a deduped sphinx under it", applehelp)
+	}
+}
+
+func TestPipExtrasAndDirectURL(t *testing.T) {
+	output := `{"installed": [
+		{"metadata": {"name": "httpx", "version": "0.27.0", "requires_dist": ["httpcore==1.*", "h2<5,>=3; extra == 'http2'"]}, "requested": true},
+		{"metadata": {"name": "app-client", "version": "0.1.0", "requires_dist": ["httpx[http2]>=0.27"]}, "requested": true,
+		 "direct_url": {"url": "file:///home/me/app-client", "dir_info": {"editable": true}}},
+		{"metadata": {"name": "httpcore", "version": "1.0.5"}},
+		{"metadata": {"name": "h2", "version": "4.1.0"}},
+		{"metadata": {"name": "toolz", "version": "0.12.1"}, "requested": true,
+		 "direct_url": {"url": "https://github.com/pytoolz/toolz.git", "vcs_info": {"vcs": "git", "commit_id": "abc123", "requested_revision": "master"}}}
+	]}`
+	result, err := resolve.Parse("pip", []byte(output))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	checkTreeResult(t, result, "pypi", 3, []depCheck{
+		{"httpx", "0.27.0", 1},
+		{"app-client", "0.1.0", 1},
+		{"toolz", "0.12.1", 0},
+	})
+
+	// httpx was first expanded without extras; app-client asks for [http2]
+	app := findDep(result.Direct, "app-client")
+	if app.Source != "path" || app.Location != "/home/me/app-client" || app.PURL != "" {
+		t.Errorf("app-client = {%q %q %q}, want local path with no PURL", app.Source, app.Location, app.PURL)
+	}
+	httpx := findDep(app.Deps, "httpx")
+	if httpx == nil || httpx.Deduped || findDep(httpx.Deps, "h2") == nil {
+		t.Errorf("httpx[http2] under app-client = %+v, want expanded with h2", httpx)
+	}
+
+	toolz := findDep(result.Direct, "toolz")
+	if toolz.Source != "git" || toolz.PURL != "pkg:pypi/toolz@0.12.1?vcs_url=git%2Bhttps:%2F%2Fgithub.com%2Fpytoolz%2Ftoolz.git%40abc123" {
+		t.Errorf("toolz = {%q %q}, want git source with vcs_url", toolz.Source, toolz.PURL)
+	}
 }
 
 func TestConda(t *testing.T) {
@@ -285,6 +677,86 @@ func TestConda(t *testing.T) {
 	}
 }
 
+func TestCondaQualifiers(t *testing.T) {
+	result, err := resolve.Parse("conda", loadFixture(t, "conda-list.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]string{
+		"numpy":      "pkg:conda/numpy@1.26.2?build=py311h08b1b3b_0&channel=pkgs%2Fmain&subdir=linux-64",
+		"tzdata":     "pkg:conda/tzdata@2023c?build=h71feb2d_0&channel=conda-forge&subdir=noarch",
+		"acme-tools": "pkg:conda/acme-tools@2.1.0?build=pyhd8ed1ab_0&channel=https:%2F%2Fconda.example.com%2Finternal&subdir=noarch",
+		"Flask":      "pkg:pypi/flask@3.0.0",
+	}
+	if len(result.Direct) != len(want) {
+		t.Fatalf("expected %d deps, got %d", len(want), len(result.Direct))
+	}
+	for name, purl := range want {
+		if dep := findDep(result.Direct, name); dep == nil || dep.PURL != purl {
+			t.Errorf("%s = %+v, want PURL %q", name, dep, purl)
+		}
+	}
+}
+
+func TestCondaEnv(t *testing.T) {
+	result, err := resolve.Parse("conda-env", loadFixture(t, "conda-environment.yml"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Ecosystem != "conda" {
+		t.Errorf("Ecosystem = %q, want %q", result.Ecosystem, "conda")
+	}
+
+	tests := []struct {
+		name, version, constraint, purl string
+	}{
+		{"_libgcc_mutex", "0.1", "", "pkg:conda/_libgcc_mutex@0.1?build=conda_forge"},
+		{"numpy", "1.26.2", "", "pkg:conda/numpy@1.26.2?build=py311h64a7726_0"},
+		{"scipy", "1.11.4", "", "pkg:conda/scipy@1.11.4?build=py311h64a7726_0&channel=conda-forge"},
+		{"python", "3.11.7", "", "pkg:conda/python@3.11.7?build=hab00c5b_1_cpython"},
+		{"pandas", "", ">=2.1", "pkg:conda/pandas"},
+		{"Flask", "3.0.0", "", "pkg:pypi/flask@3.0.0"},
+		{"requests", "2.31.0", "", "pkg:pypi/requests@2.31.0"},
+		{"zlib", "1.2.13", "", "pkg:conda/zlib@1.2.13?build=hd590300_5"},
+	}
+	if len(result.Direct) != len(tests) {
+		t.Fatalf("expected %d deps, got %d", len(tests), len(result.Direct))
+	}
+	for i, tt := range tests {
+		dep := result.Direct[i]
+		if dep.Name != tt.name || dep.Version != tt.version || dep.Constraint != tt.constraint || dep.PURL != tt.purl {
+			t.Errorf("dep %d = {%q %q %q %q}, want {%q %q %q %q}", i, dep.Name, dep.Version, dep.Constraint, dep.PURL, tt.name, tt.version, tt.constraint, tt.purl)
+		}
+	}
+}
+
+func TestCondaExplicit(t *testing.T) {
+	result, err := resolve.Parse("conda-explicit", loadFixture(t, "conda-explicit.txt"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := map[string]string{
+		"_libgcc_mutex": "pkg:conda/_libgcc_mutex@0.1?build=conda_forge&channel=conda-forge&checksum=md5:d7c89558ba9fa0495403155b64376d81&subdir=linux-64&type=tar.bz2",
+		"numpy":         "pkg:conda/numpy@1.26.2?build=py311h08b1b3b_0&channel=pkgs%2Fmain&subdir=linux-64&type=conda",
+		"python-tzdata": "pkg:conda/python-tzdata@2023.3?build=pyhd8ed1ab_0&channel=conda-forge&checksum=sha256:2b9f1ab5d1dc8a2e4bd7d0e1d7a2f9c6f8f5d3c1b4a6e7d9c0b1a2f3e4d5c6b7&subdir=noarch&type=conda",
+		"acme-tools":    "pkg:conda/acme-tools@2.0.1?build=py311_0&channel=https:%2F%2Fconda.example.com%2Fchannels%2Finternal&subdir=linux-64&type=conda",
+	}
+	if len(result.Direct) != len(want) {
+		t.Fatalf("expected %d deps, got %d", len(want), len(result.Direct))
+	}
+	for name, purl := range want {
+		dep := findDep(result.Direct, name)
+		if dep == nil || dep.PURL != purl {
+			t.Errorf("%s = %+v, want PURL %q", name, dep, purl)
+		}
+	}
+	if tzdata := findDep(result.Direct, "python-tzdata"); tzdata.Version != "2023.3" {
+		t.Errorf("python-tzdata version = %q, want %q", tzdata.Version, "2023.3")
+	}
+}
+
 func TestStack(t *testing.T) {
 	result, err := resolve.Parse("stack", loadFixture(t, "stack.json"))
 	if err != nil {
@@ -305,6 +777,100 @@ func TestStack(t *testing.T) {
 	}
 }
 
+func TestStackTree(t *testing.T) {
+	result, err := resolve.Parse("stack", loadFixture(t, "stack-deps.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	checkTreeResult(t, result, "hackage", 4, []depCheck{
+		{"aeson", "2.2.1.0", 3},
+		{"base", "4.18.1.0", 0},
+		{"text", "2.0.2", 0},
+		{"wai-extra", "3.1.14", 2},
+	})
+
+	aeson := findDep(result.Direct, "aeson")
+	if aeson.License != "BSD-3-Clause" {
+		t.Errorf("aeson license = %q, want %q", aeson.License, "BSD-3-Clause")
+	}
+	// base's own deps (rts, ghc-prim) aren't listed, so it has nothing to expand
+	if base := findDep(aeson.Deps, "base"); base == nil || base.Deduped {
+		t.Errorf("base under aeson = %+v, want expanded with no deps", base)
+	}
+	if text := findDep(result.Direct, "text"); !text.Deduped {
+		t.Error("text should be deduped after appearing under aeson")
+	}
+
+	wai := findDep(result.Direct, "wai-extra")
+	if wai.Source != "git" || wai.Location != "https://github.com/yesodweb/wai.git" || wai.License != "MIT" {
+		t.Errorf("wai-extra = {%q %q %q}, want git source with MIT license", wai.Source, wai.Location, wai.License)
+	}
+	wantPURL := "pkg:hackage/wai-extra@3.1.14?vcs_url=git%2Bhttps:%2F%2Fgithub.com%2Fyesodweb%2Fwai.git%402b9f5e6f1b7c8d9e0a1b2c3d4e5f60718293a4b5"
+	if wai.PURL != wantPURL {
+		t.Errorf("wai-extra PURL = %q, want %q", wai.PURL, wantPURL)
+	}
+}
+
+func TestStackProjects(t *testing.T) {
+	output := `[{"name":"api","version":"0.1.0","location":{"type":"project package","url":"file:///src/api/"},"dependencies":["core","text"]},
+{"name":"core","version":"0.1.0","location":{"type":"project package","url":"file:///src/core/"},"dependencies":["text"]},
+{"name":"text","version":"2.0.2","location":{"type":"hackage","url":"https://hackage.haskell.org/package/text-2.0.2"},"dependencies":[]}]`
+	result, err := resolve.Parse("stack", []byte(output))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Direct) != 2 {
+		t.Fatalf("expected 2 project roots, got %d", len(result.Direct))
+	}
+	for _, root := range result.Direct {
+		if root.Source != "workspace" || root.PURL != "" {
+			t.Errorf("%s = %+v, want workspace root with no PURL", root.Name, root)
+		}
+	}
+	if core := findDep(result.Direct[0].Deps, "core"); core == nil || core.PURL != "" {
+		t.Errorf("core under api = %+v, want no PURL", core)
+	}
+	if text := findDep(result.Direct[1].Deps, "text"); text == nil || text.PURL != "pkg:hackage/text@2.0.2" {
+		t.Errorf("text = %+v, want pkg:hackage/text@2.0.2", text)
+	}
+}
+
+func TestCabalPlan(t *testing.T) {
+	result, err := resolve.Parse("cabal", loadFixture(t, "cabal-plan.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	checkTreeResult(t, result, "hackage", 6, []depCheck{
+		{"aeson", "2.2.1.0", 2},
+		{"base", "4.18.1.0", 0},
+		{"internal-utils", "0.3.0", 1},
+		{"servant", "0.20.1", 3},
+		{"happy", "1.20.1.1", 1},
+		{"hspec", "2.11.7", 1},
+	})
+
+	scopes := map[string]string{"aeson": "", "base": "", "happy": "build", "hspec": "test"}
+	for name, want := range scopes {
+		if dep := findDep(result.Direct, name); dep.Scope != want {
+			t.Errorf("%s scope = %q, want %q", name, dep.Scope, want)
+		}
+	}
+
+	utils := findDep(result.Direct, "internal-utils")
+	if utils.PURL != "pkg:hackage/internal-utils@0.3.0?repository_url=https:%2F%2Fhackage.acme.example" {
+		t.Errorf("internal-utils PURL = %q, want repository_url", utils.PURL)
+	}
+	servant := findDep(result.Direct, "servant")
+	if servant.Source != "git" || servant.Location != "https://github.com/haskell-servant/servant.git" {
+		t.Errorf("servant source = %q %q, want git", servant.Source, servant.Location)
+	}
+	if aeson := findDep(servant.Deps, "aeson"); aeson == nil || !aeson.Deduped {
+		t.Errorf("aeson under servant = %+v, want deduped", aeson)
+	}
+}
+
 func TestDeno(t *testing.T) {
 	result, err := resolve.Parse("deno", loadFixture(t, "deno.json"))
 	if err != nil {
@@ -322,6 +888,56 @@ func TestDeno(t *testing.T) {
 	}
 }
 
+func TestDenoGraph(t *testing.T) {
+	result, err := resolve.Parse("deno", loadFixture(t, "deno-graph.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// util.ts is a local module, so its imports are direct deps too
+	checkTreeResult(t, result, "deno", 5, []depCheck{
+		{"@std/path", "1.0.8", 1},
+		{"express", expressVersion, 2},
+		{"oak", "v12.6.1", 1},
+		{"esm.sh/preact", "10.19.2", 0},
+		{"example.com/lib/banner.ts", "", 0},
+	})
+
+	purls := map[string]string{
+		"@std/path":                 "pkg:jsr/%40std/path@1.0.8",
+		"express":                   "pkg:npm/express@4.18.2",
+		"oak":                       "pkg:deno/oak@v12.6.1",
+		"esm.sh/preact":             "pkg:generic/esm.sh/preact@10.19.2?download_url=https:%2F%2Fesm.sh%2Fpreact%4010.19.2",
+		"example.com/lib/banner.ts": "pkg:generic/example.com/lib/banner.ts?download_url=https:%2F%2Fexample.com%2Flib%2Fbanner.ts",
+	}
+	for name, want := range purls {
+		if d := findDep(result.Direct, name); d.PURL != want {
+			t.Errorf("%s PURL = %q, want %q", name, d.PURL, want)
+		}
+	}
+
+	path := findDep(result.Direct, "@std/path")
+	if path.Constraint != "^1.0.8" {
+		t.Errorf("@std/path constraint = %q, want %q", path.Constraint, "^1.0.8")
+	}
+	if internal := path.Deps[0]; internal.Name != "@std/internal" || internal.Version != "1.0.5" || internal.Constraint != "^1.0.5" {
+		t.Errorf("@std/path dep = %+v, want @std/internal 1.0.5", internal)
+	}
+
+	express := findDep(result.Direct, "express")
+	body := findDep(express.Deps, "body-parser")
+	if body == nil || len(body.Deps) != 1 || body.Deps[0].Name != "debug" {
+		t.Fatalf("body-parser = %+v, want debug dependency", body)
+	}
+	if debug := findDep(express.Deps, "debug"); debug == nil || !debug.Deduped {
+		t.Errorf("second debug = %+v, want deduped", debug)
+	}
+
+	oak := findDep(result.Direct, "oak")
+	if std := oak.Deps[0]; std.Name != "std" || std.Version != "0.200.0" {
+		t.Errorf("oak dep = %+v, want std 0.200.0", std)
+	}
+}
+
 func TestSwift(t *testing.T) {
 	result, err := resolve.Parse("swift", loadFixture(t, "swift.json"))
 	if err != nil {
@@ -342,33 +958,268 @@ func TestSwift(t *testing.T) {
 	}
 }
 
-func TestUV(t *testing.T) {
-	result, err := resolve.Parse("uv", loadFixture(t, "uv.txt"))
+func TestSwiftPURLs(t *testing.T) {
+	result, err := resolve.Parse("swift", loadFixture(t, "swift-deps.json"))
 	if err != nil {
 		t.Fatalf("unexpected error: %v", err)
 	}
-	// root line "my-project v0.1.0" is parsed as depth 0, then deps at depth 0 too
-	// Since the tree starts with root, we get root + 2 direct children
-	// Actually the root line won't match "name vX" well... let me check
-	// Root: "my-project v0.1.0" -> depth 0
-	// "├── requests v2.31.0" -> depth 0
-	// "│   ├── certifi v2024.12.14" -> depth 1
-	// etc.
-	// So buildTree will see all depth-0 items as roots
-	if len(result.Direct) < 2 {
-		t.Fatalf("expected at least 2 direct deps, got %d", len(result.Direct))
-	}
+	checkTreeResult(t, result, "swift", 3, []depCheck{
+		{"swift-nio", "2.62.0", 1},
+		{"swift-log", "", 0},
+		{"SharedModels", "", 0},
+	})
 
-	// Find requests (should have transitive deps)
-	requests := findDep(result.Direct, "requests")
-	if requests == nil {
-		t.Fatal("missing requests")
+	nio := findDep(result.Direct, "swift-nio")
+	if nio.PURL != "pkg:swift/github.com/apple/swift-nio@2.62.0" {
+		t.Errorf("swift-nio PURL = %q", nio.PURL)
 	}
-	if requests.Version != "2.31.0" {
-		t.Errorf("requests version = %q, want %q", requests.Version, "2.31.0")
+	if atomics := nio.Deps[0]; atomics.PURL != "pkg:swift/github.com/apple/swift-atomics@1.2.0" {
+		t.Errorf("swift-atomics PURL = %q", atomics.PURL)
 	}
-	if len(requests.Deps) != 2 {
-		t.Errorf("requests transitive deps = %d, want 2", len(requests.Deps))
+	// Branch pin: show-dependencies reports "unspecified"
+	if log := findDep(result.Direct, "swift-log"); log.Version != "" || log.PURL != "pkg:swift/github.com/apple/swift-log" {
+		t.Errorf("swift-log = %+v, want unversioned PURL from ssh URL", log)
+	}
+	shared := findDep(result.Direct, "SharedModels")
+	if shared.Source != "path" || shared.Location != "/Users/dev/SharedModels" || shared.PURL != "" {
+		t.Errorf("SharedModels = %+v, want local path package with no PURL", shared)
+	}
+}
+
+func TestSwiftResolved(t *testing.T) {
+	result, err := resolve.Parse("swift-resolved", loadFixture(t, "Package.resolved"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Direct) != 4 {
+		t.Fatalf("expected 4 pins, got %d", len(result.Direct))
+	}
+	if nio := findDep(result.Direct, "swift-nio"); nio.PURL != "pkg:swift/github.com/apple/swift-nio@2.62.0" || nio.Deps != nil {
+		t.Errorf("swift-nio = %+v, want flat pin", nio)
+	}
+	log := findDep(result.Direct, "swift-log")
+	if log.Version != "" || log.Constraint != "main" {
+		t.Errorf("swift-log = %+v, want unversioned pin on main", log)
+	}
+	if want := "pkg:swift/github.com/apple/swift-log?vcs_url=git%2Bhttps:%2F%2Fgithub.com%2Fapple%2Fswift-log%40e97a6fcb1ab07462881ac165fdbb37f067e205d5"; log.PURL != want {
+		t.Errorf("swift-log PURL = %q, want %q", log.PURL, want)
+	}
+	if mona := findDep(result.Direct, "mona.linkedlist"); mona.PURL != "pkg:swift/mona/linkedlist@1.1.0" {
+		t.Errorf("registry pin PURL = %q, want pkg:swift/mona/linkedlist@1.1.0", mona.PURL)
+	}
+}
+
+func TestSwiftResolvedV1(t *testing.T) {
+	input := `{
+  "object": {
+    "pins": [
+      {
+        "package": "swift-argument-parser",
+        "repositoryURL": "https://github.com/apple/swift-argument-parser",
+        "state": {
+          "branch": null,
+          "revision": "fddd1c00396eed152c45a46bea9f47b98e59301d",
+          "version": "1.2.0"
+        }
+      }
+    ]
+  },
+  "version": 1
+}`
+	result, err := resolve.Parse("swift-resolved", []byte(input))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Direct) != 1 {
+		t.Fatalf("expected 1 pin, got %d", len(result.Direct))
+	}
+	if d := result.Direct[0]; d.Name != "swift-argument-parser" || d.PURL != "pkg:swift/github.com/apple/swift-argument-parser@1.2.0" {
+		t.Errorf("pin = %+v", d)
+	}
+}
+
+func TestCocoaPods(t *testing.T) {
+	result, err := resolve.Parse("cocoapods", loadFixture(t, "Podfile.lock"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	checkTreeResult(t, result, "cocoapods", 5, []depCheck{
+		{"Alamofire", "5.8.1", 0},
+		{"Firebase/Analytics", "10.18.0", 1},
+		{"InternalKit", "2.1.0", 1},
+		{"MyLib", "0.1.0", 1},
+		{"SnapKit", "5.6.0", 0},
+	})
+
+	analytics := findDep(result.Direct, "Firebase/Analytics")
+	if analytics.PURL != "pkg:cocoapods/Firebase@10.18.0#Analytics" {
+		t.Errorf("Firebase/Analytics PURL = %q, want subspec as subpath", analytics.PURL)
+	}
+	core := findDep(analytics.Deps, "Firebase/Core")
+	fa := findDep(core.Deps, "FirebaseAnalytics")
+	if fa == nil || fa.Constraint != "~> 10.18.0" {
+		t.Fatalf("FirebaseAnalytics = %+v, want constraint ~> 10.18.0", fa)
+	}
+	if fc := findDep(fa.Deps, "FirebaseCore"); fc == nil || !fc.Deduped {
+		t.Errorf("FirebaseCore under FirebaseAnalytics = %+v, want deduped", fc)
+	}
+	if zlib := findDep(fa.Deps, "GoogleUtilities/NSData+zlib"); zlib == nil || zlib.PURL != "pkg:cocoapods/GoogleUtilities@7.12.0#NSData%2Bzlib" {
+		t.Errorf("quoted subspec = %+v", zlib)
+	}
+
+	internal := findDep(result.Direct, "InternalKit")
+	if internal.PURL != "pkg:cocoapods/InternalKit@2.1.0?repository_url=https:%2F%2Fgithub.com%2Facme%2FSpecs.git" {
+		t.Errorf("InternalKit PURL = %q, want private spec repo", internal.PURL)
+	}
+	if mylib := findDep(result.Direct, "MyLib"); mylib.Source != "path" || mylib.Location != "../MyLib" || mylib.Constraint != "" {
+		t.Errorf("MyLib = %+v, want path source", mylib)
+	}
+	snapkit := findDep(result.Direct, "SnapKit")
+	if snapkit.Source != "git" || snapkit.Location != "https://github.com/SnapKit/SnapKit.git" {
+		t.Errorf("SnapKit source = {%q %q}, want git", snapkit.Source, snapkit.Location)
+	}
+	if want := "pkg:cocoapods/SnapKit@5.6.0?vcs_url=git%2Bhttps:%2F%2Fgithub.com%2FSnapKit%2FSnapKit.git%402f9e8b5ac1d2d1a1e5b5d9d54b2a0c5d5e6f7a8b"; snapkit.PURL != want {
+		t.Errorf("SnapKit PURL = %q, want %q", snapkit.PURL, want)
+	}
+}
+
+func TestCarthage(t *testing.T) {
+	result, err := resolve.Parse("carthage", loadFixture(t, "Cartfile.resolved"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Direct) != 6 {
+		t.Fatalf("expected 6 deps, got %d", len(result.Direct))
+	}
+
+	tests := []struct {
+		name, source, purl string
+	}{
+		{"Keychain", "git", "pkg:generic/github.com/acme/Keychain@3.1.0?vcs_url=git%2Bssh:%2F%2Fgit%40github.com%2Facme%2FKeychain.git%403.1.0"},
+		{"Alamofire/Alamofire", "", "pkg:github/alamofire/alamofire@5.8.1"},
+		{"Networking", "git", "pkg:generic/gitlab.com/acme/Networking@v1.4.2?vcs_url=git%2Bhttps:%2F%2Fgitlab.com%2Facme%2FNetworking.git%40v1.4.2"},
+		{"Theme", "git", "pkg:generic/ghe.acme.com/ios/Theme@2.0.0?vcs_url=git%2Bhttps:%2F%2Fghe.acme.com%2Fios%2FTheme%402.0.0"},
+		{"FirebaseAnalyticsBinary", "url", "pkg:generic/FirebaseAnalyticsBinary@10.18.0?download_url=https:%2F%2Fdl.google.com%2Fdl%2Ffirebase%2Fios%2Fcarthage%2FFirebaseAnalyticsBinary.json"},
+	}
+	for _, tt := range tests {
+		dep := findDep(result.Direct, tt.name)
+		if dep == nil {
+			t.Errorf("missing %s", tt.name)
+			continue
+		}
+		if dep.Source != tt.source || dep.PURL != tt.purl || dep.Deps != nil {
+			t.Errorf("%s = {%q %q}, want {%q %q}", tt.name, dep.Source, dep.PURL, tt.source, tt.purl)
+		}
+	}
+}
+
+func TestUV(t *testing.T) {
+	result, err := resolve.Parse("uv", loadFixture(t, "uv.txt"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// root line "my-project v0.1.0" is parsed as depth 0, then deps at depth 0 too
+	// Since the tree starts with root, we get root + 2 direct children
+	// Actually the root line won't match "name vX" well... let me check
+	// Root: "my-project v0.1.0" -> depth 0
+	// "├── requests v2.31.0" -> depth 0
+	// "│   ├── certifi v2024.12.14" -> depth 1
+	// etc.
+	// So buildTree will see all depth-0 items as roots
+	if len(result.Direct) < 2 {
+		t.Fatalf("expected at least 2 direct deps, got %d", len(result.Direct))
+	}
+
+	// Find requests (should have transitive deps)
+	requests := findDep(result.Direct, "requests")
+	if requests == nil {
+		t.Fatal("missing requests")
+	}
+	if requests.Version != "2.31.0" {
+		t.Errorf("requests version = %q, want %q", requests.Version, "2.31.0")
+	}
+	if len(requests.Deps) != 2 {
+		t.Errorf("requests transitive deps = %d, want 2", len(requests.Deps))
+	}
+}
+
+func TestUVProjectLine(t *testing.T) {
+	result, err := resolve.Parse("uv", loadFixture(t, "uv.txt"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The project line itself is not a dependency
+	if len(result.Direct) != 2 || findDep(result.Direct, "my-project") != nil {
+		t.Errorf("direct deps = %d, want requests and flask without the project", len(result.Direct))
+	}
+}
+
+func TestUVAnnotations(t *testing.T) {
+	result, err := resolve.Parse("uv", loadFixture(t, "uv-annotated.txt"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	checkTreeResult(t, result, "pypi", 3, []depCheck{
+		{"httpx", "0.27.0", 6},
+	})
+
+	requests := findDep(result.Direct, "requests")
+	if requests == nil {
+		t.Fatal("missing requests")
+	}
+	if requests.Version != "2.31.0" {
+		t.Errorf("requests version = %q, want %q", requests.Version, "2.31.0")
+	}
+	pysocks := findDep(requests.Deps, "pysocks")
+	if pysocks == nil {
+		t.Fatal("missing pysocks under requests")
+	}
+	if pysocks.Version != "1.7.1" || pysocks.Extra != "socks" {
+		t.Errorf("pysocks = %q (extra %q), want 1.7.1 (socks)", pysocks.Version, pysocks.Extra)
+	}
+
+	pytest := findDep(result.Direct, "pytest")
+	if pytest == nil {
+		t.Fatal("missing pytest")
+	}
+	if pytest.Version != "8.0.0" || pytest.Scope != "dev" {
+		t.Errorf("pytest = %q (scope %q), want 8.0.0 (dev)", pytest.Version, pytest.Scope)
+	}
+	anyio := findDep(pytest.Deps, "anyio")
+	if anyio == nil || anyio.Version != "4.2.0" || !anyio.Deduped {
+		t.Errorf("anyio under pytest = %+v, want deduplicated 4.2.0", anyio)
+	}
+}
+
+func TestUVPip(t *testing.T) {
+	result, err := resolve.Parse("uv-pip", loadFixture(t, "uv-pip.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	checkTreeResult(t, result, "pypi", 4, []depCheck{
+		{"requests", "2.31.0", 0},
+	})
+	if findDep(result.Direct, "requests").Deps != nil {
+		t.Error("uv pip list deps should have nil Deps (flat list)")
+	}
+}
+
+func TestUVExport(t *testing.T) {
+	result, err := resolve.Parse("uv-export", loadFixture(t, "uv-export.txt"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// Packages "via my-project" are direct
+	checkTreeResult(t, result, "pypi", 3, []depCheck{
+		{"click", "8.1.7", 1},
+		{"httpcore", "1.0.2", 2},
+		{"requests", "2.31.0", 4},
+	})
+	requests := findDep(result.Direct, "requests")
+	certifi := findDep(requests.Deps, "certifi")
+	if certifi == nil || certifi.Version != "2024.12.14" {
+		t.Errorf("certifi under requests = %+v, want 2024.12.14", certifi)
 	}
 }
 
@@ -401,6 +1252,57 @@ func TestPoetry(t *testing.T) {
 	}
 }
 
+func TestPoetryNested(t *testing.T) {
+	result, err := resolve.Parse("poetry", loadFixture(t, "poetry-nested.txt"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The appended flat listing only supplies versions
+	checkTreeResult(t, result, "pypi", 2, []depCheck{
+		{"flask", "3.0.0", 5},
+		{"requests", "2.31.0", 4},
+	})
+
+	flask := findDep(result.Direct, "flask")
+	click := findDep(flask.Deps, "click")
+	if click == nil {
+		t.Fatal("missing click under flask")
+	}
+	if click.Version != "8.1.7" || click.Constraint != ">=8.1.3" {
+		t.Errorf("click = %q (constraint %q), want 8.1.7 (>=8.1.3)", click.Version, click.Constraint)
+	}
+	colorama := findDep(click.Deps, "colorama")
+	if colorama == nil {
+		t.Fatal("missing colorama under click")
+	}
+	if colorama.Version != "0.4.6" || colorama.Constraint != "*" {
+		t.Errorf("colorama = %q (constraint %q), want 0.4.6 (*)", colorama.Version, colorama.Constraint)
+	}
+	werkzeug := findDep(flask.Deps, "werkzeug")
+	markupsafe := findDep(werkzeug.Deps, "markupsafe")
+	if markupsafe == nil || markupsafe.Version != "2.1.3" {
+		t.Errorf("markupsafe under werkzeug = %+v, want version 2.1.3", markupsafe)
+	}
+}
+
+func TestPoetryCircular(t *testing.T) {
+	output := `sphinx 7.2.6 Python documentation generator
+└── sphinxcontrib-applehelp *
+    └── sphinx >=5 (circular dependency aborted here)
+`
+	result, err := resolve.Parse("poetry", []byte(output))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	applehelp := result.Direct[0].Deps[0]
+	if len(applehelp.Deps) != 1 {
+		t.Fatalf("applehelp deps = %+v, want sphinx", applehelp.Deps)
+	}
+	if sphinx := applehelp.Deps[0]; sphinx.Constraint != ">=5" || !sphinx.Deduped {
+		t.Errorf("sphinx under applehelp = %+v, want Deduped with constraint >=5", sphinx)
+	}
+}
+
 func TestBundler(t *testing.T) {
 	result, err := resolve.Parse("bundler", loadFixture(t, "bundler.txt"))
 	if err != nil {
@@ -466,6 +1368,78 @@ func TestGradle(t *testing.T) {
 	}
 }
 
+func TestGemfileLock(t *testing.T) {
+	result, err := resolve.Parse("bundler-lock", loadFixture(t, "Gemfile.lock"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// nokogiri has two platform builds, so it appears twice
+	checkTreeResult(t, result, "gem", 6, []depCheck{
+		{"acme-client", "2.0.1", 2},
+		{"billing", "0.1.0", 1},
+		{"devise", "4.9.3", 2},
+		{"rake", "13.1.0", 0},
+	})
+
+	acme := findDep(result.Direct, "acme-client")
+	if acme.PURL != "pkg:gem/acme-client@2.0.1?repository_url=https:%2F%2Fgems.example.com%2F" {
+		t.Errorf("acme-client PURL = %q, want repository_url qualifier", acme.PURL)
+	}
+	arm := acme.Deps[0]
+	if arm.Name != "nokogiri" || arm.Version != "1.16.0" || arm.Target != "arm64-darwin" || arm.Constraint != ">= 1.13" {
+		t.Errorf("first nokogiri = %+v, want arm64-darwin build", arm)
+	}
+	if arm.PURL != "pkg:gem/nokogiri@1.16.0?platform=arm64-darwin" {
+		t.Errorf("nokogiri PURL = %q, want platform qualifier", arm.PURL)
+	}
+	if len(arm.Deps) != 1 || arm.Deps[0].Name != "racc" || arm.Deps[0].Constraint != "~> 1.4" {
+		t.Errorf("nokogiri deps = %+v, want racc", arm.Deps)
+	}
+
+	billing := findDep(result.Direct, "billing")
+	if billing.Source != "path" || billing.Location != "engines/billing" || billing.PURL != "pkg:gem/billing@0.1.0" {
+		t.Errorf("billing = %+v, want path gem", billing)
+	}
+	devise := findDep(result.Direct, "devise")
+	if devise.Source != "git" || devise.Location != "https://github.com/heartcombo/devise.git" {
+		t.Errorf("devise = %+v, want git gem", devise)
+	}
+	if devise.PURL != "pkg:gem/devise@4.9.3?vcs_url=git%2Bhttps:%2F%2Fgithub.com%2Fheartcombo%2Fdevise.git%402c2a1e5d3f4b6c7a8e9f0d1c2b3a4f5e6d7c8b9a" {
+		t.Errorf("devise PURL = %q, want vcs_url qualifier", devise.PURL)
+	}
+	// billing comes first in DEPENDENCIES, so railties is expanded there
+	railties := findDep(billing.Deps, "railties")
+	if railties == nil || railties.Deduped || len(railties.Deps) != 2 {
+		t.Fatalf("railties under billing = %+v, want expanded", railties)
+	}
+	if thor := findDep(railties.Deps, "thor"); thor.Constraint != "~> 1.0, >= 1.2.2" {
+		t.Errorf("thor constraint = %q", thor.Constraint)
+	}
+	if r := findDep(devise.Deps, "railties"); !r.Deduped || len(r.Deps) != 0 {
+		t.Errorf("railties under devise = %+v, want deduped", r)
+	}
+}
+
+func TestGemfileLockGroups(t *testing.T) {
+	result, err := resolve.Parse("bundler-lock", loadFixture(t, "Gemfile-groups.txt"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Direct) != 5 {
+		t.Fatalf("expected 5 direct deps, got %d", len(result.Direct))
+	}
+	scopes := map[string]string{"pry": "dev", "rails": "", "rspec": "dev", "rubocop": "lint", "widgets": ""}
+	for name, want := range scopes {
+		if dep := findDep(result.Direct, name); dep == nil || dep.Scope != want {
+			t.Errorf("%s = %+v, want scope %q", name, dep, want)
+		}
+	}
+	widgets := findDep(result.Direct, "widgets")
+	if widgets.PURL != "pkg:gem/widgets@0.3.0?vcs_url=git%2Bssh:%2F%2Fgit%40github.com%2Facme%2Fwidgets.git%409f8e7d6c5b4a39281706f5e4d3c2b1a098765432" {
+		t.Errorf("widgets PURL = %q, want ssh vcs_url", widgets.PURL)
+	}
+}
+
 func TestComposer(t *testing.T) {
 	result, err := resolve.Parse("composer", loadFixture(t, "composer.txt"))
 	if err != nil {
@@ -485,6 +1459,96 @@ func TestComposer(t *testing.T) {
 	}
 }
 
+func TestComposerTreeStyles(t *testing.T) {
+	// Older composer draws "├── name version description"
+	result, err := resolve.Parse("composer", loadFixture(t, "composer.txt"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	laravel := findDep(result.Direct, "laravel/framework")
+	guzzle := findDep(laravel.Deps, "guzzlehttp/guzzle")
+	if guzzle == nil || guzzle.Version != "7.8.1" || guzzle.Constraint != "" || guzzle.PURL != "pkg:composer/guzzlehttp/guzzle@7.8.1" {
+		t.Errorf("guzzle = %+v, want installed version 7.8.1", guzzle)
+	}
+
+	// Recent composer draws "├──name constraint"
+	result, err = resolve.Parse("composer", loadFixture(t, "composer-tree.txt"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	checkTreeResult(t, result, "packagist", 2, []depCheck{
+		{"laravel/framework", "v10.38.1", 3},
+		{"monolog/monolog", "3.5.0", 2},
+	})
+	laravel = findDep(result.Direct, "laravel/framework")
+	guzzle = findDep(laravel.Deps, "guzzlehttp/guzzle")
+	if guzzle == nil || guzzle.Version != "" || guzzle.Constraint != "^7.2" || len(guzzle.Deps) != 2 {
+		t.Errorf("guzzle = %+v, want constraint ^7.2 with 2 deps", guzzle)
+	}
+	inflector := findDep(laravel.Deps, "doctrine/inflector")
+	if php := findDep(inflector.Deps, "php"); php == nil || php.Source != "platform" || php.Constraint != "^7.2 || ^8.0" {
+		t.Errorf("php under doctrine/inflector = %+v, want platform requirement", php)
+	}
+
+	// Rows that don't name a package are skipped
+	output := "acme/app 1.0.0\n├── (no dependencies listed)\n└── psr/log 3.0.0\nNo lock file found\n"
+	result, err = resolve.Parse("composer", []byte(output))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	checkTreeResult(t, result, "packagist", 1, []depCheck{
+		{"acme/app", "1.0.0", 1},
+	})
+}
+
+func TestComposerConstraints(t *testing.T) {
+	result, err := resolve.Parse("composer", loadFixture(t, "composer-listing.txt"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// The appended flat listing only supplies versions
+	checkTreeResult(t, result, "packagist", 2, []depCheck{
+		{"guzzlehttp/guzzle", "7.8.1", 4},
+		{"psr/http-message", "2.0", 1},
+	})
+
+	guzzle := findDep(result.Direct, "guzzlehttp/guzzle")
+	psr7 := findDep(guzzle.Deps, "guzzlehttp/psr7")
+	if psr7.Version != "2.6.2" || psr7.Constraint != "^1.9.1 || ^2.5.1" || psr7.PURL != "pkg:composer/guzzlehttp/psr7@2.6.2" {
+		t.Errorf("psr7 = %+v, want 2.6.2 backfilled with constraint kept", psr7)
+	}
+	if msg := findDep(psr7.Deps, "psr/http-message"); msg == nil || msg.Version != "2.0" {
+		t.Errorf("psr/http-message under psr7 = %+v, want version from top-level line", msg)
+	}
+
+	php := findDep(guzzle.Deps, "php")
+	if php.Source != "platform" || php.PURL != "" || php.Version != "" || php.Constraint != "^7.2.5 || ^8.0" {
+		t.Errorf("php = %+v, want platform requirement without PURL", php)
+	}
+	if ext := findDep(guzzle.Deps, "ext-json"); ext.Source != "platform" || ext.Constraint != "*" {
+		t.Errorf("ext-json = %+v, want platform requirement", ext)
+	}
+}
+
+func TestComposerLockedJSON(t *testing.T) {
+	result, err := resolve.Parse("composer", loadFixture(t, "composer-locked.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	checkTreeResult(t, result, "packagist", 2, []depCheck{
+		{"monolog/monolog", "3.5.0", 2},
+		{"psr/log", "3.0.0", 1},
+	})
+	monolog := findDep(result.Direct, "monolog/monolog")
+	log := findDep(monolog.Deps, "psr/log")
+	if log.Version != "3.0.0" || log.Constraint != "^2.0 || ^3.0" || len(log.Deps) != 1 {
+		t.Errorf("psr/log = %+v, want 3.0.0 with constraint and 1 dep", log)
+	}
+	if php := findDep(monolog.Deps, "php"); php.Source != "platform" || php.Constraint != ">=8.1" {
+		t.Errorf("php = %+v, want platform requirement", php)
+	}
+}
+
 func TestNuget(t *testing.T) {
 	result, err := resolve.Parse("nuget", loadFixture(t, "nuget.txt"))
 	if err != nil {
@@ -506,6 +1570,125 @@ func TestNuget(t *testing.T) {
 	}
 }
 
+func TestNugetJSON(t *testing.T) {
+	result, err := resolve.Parse("nuget", loadFixture(t, "nuget.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// One root per project and framework; Acme.Tool has no packages
+	if len(result.Direct) != 3 {
+		t.Fatalf("expected 3 roots, got %d", len(result.Direct))
+	}
+
+	api := result.Direct[0]
+	if api.Name != "Acme.Api" || api.Target != "net8.0" || api.Source != "project" ||
+		api.Location != "/home/user/src/Acme/src/Acme.Api/Acme.Api.csproj" {
+		t.Errorf("first root = %+v, want Acme.Api project for net8.0", api)
+	}
+	if len(api.Deps) != 4 {
+		t.Fatalf("Acme.Api deps = %d, want 4", len(api.Deps))
+	}
+	newtonsoft := findDep(api.Deps, "Newtonsoft.Json")
+	if newtonsoft.Version != "13.0.3" || newtonsoft.Constraint != "[13.0.1, )" || newtonsoft.Indirect {
+		t.Errorf("Newtonsoft.Json = %+v, want top-level 13.0.3 requested as [13.0.1, )", newtonsoft)
+	}
+	if newtonsoft.PURL != "pkg:nuget/Newtonsoft.Json@13.0.3" {
+		t.Errorf("Newtonsoft.Json PURL = %q", newtonsoft.PURL)
+	}
+	abstractions := findDep(api.Deps, "Microsoft.Extensions.Logging.Abstractions")
+	if abstractions == nil || !abstractions.Indirect || abstractions.Constraint != "" {
+		t.Errorf("Logging.Abstractions = %+v, want indirect", abstractions)
+	}
+
+	core := result.Direct[2]
+	if core.Name != "Acme.Core" || core.Target != "netstandard2.0" || len(core.Deps) != 2 {
+		t.Errorf("third root = %+v, want Acme.Core for netstandard2.0 with 2 deps", core)
+	}
+	if memory := findDep(core.Deps, "System.Memory"); memory == nil || memory.Target != "netstandard2.0" {
+		t.Errorf("System.Memory = %+v, want target netstandard2.0", memory)
+	}
+}
+
+func TestNugetMultipleProjects(t *testing.T) {
+	output := `Project 'Acme.Api' has the following package references
+   [net8.0]:
+   Top-level Package      Requested   Resolved   Latest
+   > Newtonsoft.Json      13.0.1      13.0.1     13.0.3
+
+   Transitive Package                           Resolved   Latest
+   > System.Text.Encodings.Web                  8.0.0      9.0.0
+
+Project 'Acme.Core' has the following package references
+   [netstandard2.0]:
+   Top-level Package      Requested   Resolved   Latest
+   > NETStandard.Library  (A)   [2.0.3, )   2.0.3      2.0.3
+`
+	result, err := resolve.Parse("nuget", []byte(output))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Direct) != 2 {
+		t.Fatalf("expected 2 roots, got %d", len(result.Direct))
+	}
+	api := result.Direct[0]
+	if api.Name != "Acme.Api" || api.Target != "net8.0" || api.PURL != "" || len(api.Deps) != 2 {
+		t.Fatalf("first root = %+v, want Acme.Api with 2 deps and no PURL", api)
+	}
+	if d := api.Deps[0]; d.Version != "13.0.1" || d.Constraint != "13.0.1" || d.Indirect {
+		t.Errorf("Newtonsoft.Json = %+v, want resolved 13.0.1, not the latest column", d)
+	}
+	if d := api.Deps[1]; d.Version != "8.0.0" || !d.Indirect {
+		t.Errorf("System.Text.Encodings.Web = %+v, want indirect 8.0.0", d)
+	}
+	if d := result.Direct[1].Deps[0]; d.Name != "NETStandard.Library" || d.Version != "2.0.3" || d.Constraint != "[2.0.3, )" {
+		t.Errorf("NETStandard.Library = %+v, want 2.0.3 requested as [2.0.3, )", d)
+	}
+}
+
+func TestNugetAssets(t *testing.T) {
+	result, err := resolve.Parse("nuget-assets", loadFixture(t, "project.assets.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// net8.0 and netstandard2.0; the linux-x64 runtime graph is skipped
+	if len(result.Direct) != 2 {
+		t.Fatalf("expected 2 framework roots, got %d", len(result.Direct))
+	}
+	net8 := result.Direct[0]
+	if net8.Name != "Acme.Api" || net8.Target != "net8.0" || net8.Source != "project" || net8.PURL != "" {
+		t.Errorf("first root = %+v, want Acme.Api for net8.0", net8)
+	}
+
+	checkTreeResult(t, &resolve.Result{Ecosystem: "nuget", Direct: net8.Deps}, "nuget", 3, []depCheck{
+		{"Microsoft.Extensions.Logging", "8.0.0", 2},
+		{"Newtonsoft.Json", "13.0.3", 0},
+		{"Acme.Core", "1.0.0", 1},
+	})
+	logging := findDep(net8.Deps, "Microsoft.Extensions.Logging")
+	if logging.Constraint != ">= 8.0.0" {
+		t.Errorf("Logging constraint = %q, want %q", logging.Constraint, ">= 8.0.0")
+	}
+	abstractions := findDep(logging.Deps, "Microsoft.Extensions.Logging.Abstractions")
+	if abstractions == nil || len(abstractions.Deps) != 1 || abstractions.Constraint != "8.0.0" {
+		t.Errorf("Logging.Abstractions = %+v, want 1 dep and constraint 8.0.0", abstractions)
+	}
+	core := findDep(net8.Deps, "Acme.Core")
+	if core.Source != "project" || core.Location != "../Acme.Core/Acme.Core.csproj" || core.PURL != "" {
+		t.Errorf("Acme.Core = %+v, want project reference without PURL", core)
+	}
+	if core.Deps[0].Target != "net8.0" {
+		t.Errorf("System.Text.Json target = %q, want net8.0", core.Deps[0].Target)
+	}
+
+	netstandard := result.Direct[1]
+	if netstandard.Target != "netstandard2.0" || len(netstandard.Deps) != 2 {
+		t.Fatalf("second root = %+v, want netstandard2.0 with 2 deps", netstandard)
+	}
+	if lib := findDep(netstandard.Deps, "NETStandard.Library"); lib == nil || len(lib.Deps) != 1 {
+		t.Errorf("NETStandard.Library = %+v, want 1 dep", lib)
+	}
+}
+
 func TestPub(t *testing.T) {
 	result, err := resolve.Parse("pub", loadFixture(t, "pub.txt"))
 	if err != nil {
@@ -526,6 +1709,75 @@ func TestPub(t *testing.T) {
 	}
 }
 
+func TestPubSections(t *testing.T) {
+	result, err := resolve.Parse("pub", loadFixture(t, "pub-sections.txt"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	checkTreeResult(t, result, "pub", 5, []depCheck{
+		{"http", "1.1.2", 2},
+		{"test", "1.24.9", 0},
+	})
+
+	scopes := map[string]string{"http": "", "test": "dev", "lints": "dev"}
+	for name, want := range scopes {
+		if dep := findDep(result.Direct, name); dep.Scope != want {
+			t.Errorf("%s scope = %q, want %q", name, dep.Scope, want)
+		}
+	}
+	if last := result.Direct[len(result.Direct)-1]; last.Name != "path" || last.Scope != "override" {
+		t.Errorf("last dep = %s scope %q, want path override", last.Name, last.Scope)
+	}
+}
+
+func TestPubJSON(t *testing.T) {
+	result, err := resolve.Parse("pub", loadFixture(t, "pub-deps.txt"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	checkTreeResult(t, result, "pub", 7, []depCheck{
+		{"flutter", "0.0.0", 2},
+		{"http", "1.1.2", 3},
+		{"acme_ui", "2.3.0", 1},
+		{"shared", "0.4.0", 1},
+		{"flutter_test", "0.0.0", 1},
+		{"lints", "3.0.0", 0},
+		{"local_tools", "0.1.0", 0},
+	})
+
+	flutter := findDep(result.Direct, "flutter")
+	if flutter.Source != "sdk" || flutter.Location != "flutter" || flutter.PURL != "" {
+		t.Errorf("flutter = {%q %q %q}, want sdk source with no PURL", flutter.Source, flutter.Location, flutter.PURL)
+	}
+	if http := findDep(result.Direct, "http"); http.PURL != "pkg:pub/http@1.1.2" {
+		t.Errorf("http PURL = %q, want no repository_url for pub.dev", http.PURL)
+	}
+	if acme := findDep(result.Direct, "acme_ui"); acme.PURL != "pkg:pub/acme_ui@2.3.0?repository_url=https:%2F%2Fdart.acme.example" {
+		t.Errorf("acme_ui PURL = %q, want repository_url", acme.PURL)
+	}
+	shared := findDep(result.Direct, "shared")
+	if shared.Source != "git" || shared.Location != "https://github.com/acme/shared.git" {
+		t.Errorf("shared source = %q %q, want git", shared.Source, shared.Location)
+	}
+	if shared.PURL != "pkg:pub/shared@0.4.0?vcs_url=git%2Bhttps:%2F%2Fgithub.com%2Facme%2Fshared.git%408f1d2e3c4b5a69788796a5b4c3d2e1f0a9b8c7d6" {
+		t.Errorf("shared PURL = %q, want vcs_url at the resolved ref", shared.PURL)
+	}
+	if lints := findDep(result.Direct, "lints"); lints.Scope != "dev" {
+		t.Errorf("lints scope = %q, want dev", lints.Scope)
+	}
+	if tools := findDep(result.Direct, "local_tools"); tools.Source != "path" || tools.Location != "../local_tools" || tools.PURL != "" {
+		t.Errorf("local_tools = %+v, want path with no PURL", tools)
+	}
+
+	http := findDep(result.Direct, "http")
+	async := findDep(http.Deps, "async")
+	if async == nil || len(async.Deps) != 2 {
+		t.Fatalf("async = %+v, want 2 deps", async)
+	}
+}
+
 func TestMix(t *testing.T) {
 	result, err := resolve.Parse("mix", loadFixture(t, "mix.txt"))
 	if err != nil {
@@ -549,6 +1801,52 @@ func TestMix(t *testing.T) {
 	}
 }
 
+func TestMixConstraints(t *testing.T) {
+	result, err := resolve.Parse("mix", loadFixture(t, "mix-deps.txt"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	checkTreeResult(t, result, "hex", 4, []depCheck{
+		{"phoenix", "1.7.10", 3},
+		{"jason", "1.4.1", 0},
+		{"my_lib", "0.1.0", 1},
+		{"ueberauth", "0.10.8", 1},
+	})
+
+	phoenix := findDep(result.Direct, "phoenix")
+	if phoenix.Constraint != "~> 1.7.10" {
+		t.Errorf("phoenix constraint = %q, want %q", phoenix.Constraint, "~> 1.7.10")
+	}
+	plug := findDep(phoenix.Deps, "plug")
+	if plug == nil || plug.Version != "1.15.2" || plug.Constraint != "~> 1.14" {
+		t.Fatalf("plug = %+v, want 1.15.2 with constraint ~> 1.14", plug)
+	}
+	mime := findDep(plug.Deps, "mime")
+	if mime == nil || mime.Constraint != "~> 1.0 or ~> 2.0" || mime.PURL != "pkg:hex/mime@2.0.5" {
+		t.Errorf("mime = %+v, want constraint %q and version 2.0.5", mime, "~> 1.0 or ~> 2.0")
+	}
+
+	jason := findDep(result.Direct, "jason")
+	if jason.Constraint != "~> 1.2" {
+		t.Errorf("jason constraint = %q, want %q", jason.Constraint, "~> 1.2")
+	}
+
+	myLib := findDep(result.Direct, "my_lib")
+	if myLib.Source != "path" || myLib.Location != "../my_lib" || myLib.PURL != "" {
+		t.Errorf("my_lib = %+v, want path ../my_lib with no PURL", myLib)
+	}
+
+	ueberauth := findDep(result.Direct, "ueberauth")
+	if ueberauth.Source != "git" || ueberauth.Location != "https://github.com/ueberauth/ueberauth.git" {
+		t.Errorf("ueberauth source = %q %q, want git", ueberauth.Source, ueberauth.Location)
+	}
+	wantPURL := "pkg:hex/ueberauth@0.10.8?vcs_url=git%2Bhttps:%2F%2Fgithub.com%2Fueberauth%2Fueberauth.git%401f2ab3c9a8b7e6d5c4b3a2f1e0d9c8b7a6f5e4d3"
+	if ueberauth.PURL != wantPURL {
+		t.Errorf("ueberauth PURL = %q, want %q", ueberauth.PURL, wantPURL)
+	}
+}
+
 func TestRebar3(t *testing.T) {
 	result, err := resolve.Parse("rebar3", loadFixture(t, "rebar3.txt"))
 	if err != nil {
@@ -572,6 +1870,213 @@ func TestRebar3(t *testing.T) {
 	}
 }
 
+func TestRebar3Sources(t *testing.T) {
+	result, err := resolve.Parse("rebar3", loadFixture(t, "rebar3-sources.txt"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	checkTreeResult(t, result, "hex", 3, []depCheck{
+		{"cowboy", "2.10.0", 2},
+		{"jsx", "v3.1.0", 0},
+		{"my_worker", "0.1.0", 1},
+	})
+
+	if cowboy := findDep(result.Direct, "cowboy"); cowboy.Source != "" {
+		t.Errorf("cowboy source = %q, want empty", cowboy.Source)
+	}
+	if jsx := findDep(result.Direct, "jsx"); jsx.Source != "git" {
+		t.Errorf("jsx source = %q, want %q", jsx.Source, "git")
+	}
+	if worker := findDep(result.Direct, "my_worker"); worker.Source != "workspace" || worker.PURL != "" {
+		t.Errorf("my_worker = %+v, want workspace app with no PURL", worker)
+	}
+}
+
+func TestRebar3WithLock(t *testing.T) {
+	result, err := resolve.Parse("rebar3", loadFixture(t, "rebar3-lock.txt"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Direct) != 4 {
+		t.Fatalf("expected 4 direct deps, got %d", len(result.Direct))
+	}
+
+	jsx := findDep(result.Direct, "jsx")
+	if jsx.Version != "v3.1.0" || jsx.Location != "git@github.com:talentdeficit/jsx.git" {
+		t.Errorf("jsx = %+v, want v3.1.0 from git@github.com:talentdeficit/jsx.git", jsx)
+	}
+	if jsx.PURL != "pkg:hex/jsx@v3.1.0?vcs_url=git%2Bssh:%2F%2Fgit%40github.com%2Ftalentdeficit%2Fjsx.git%40bc56ba4e7bbc0fe6ee9f7ed6ed6d8fd1a9dc6a56" {
+		t.Errorf("jsx PURL = %q, want ssh vcs_url at the locked ref", jsx.PURL)
+	}
+	if lager := findDep(result.Direct, "lager"); !strings.HasSuffix(lager.PURL, "lager.git%40master") {
+		t.Errorf("lager PURL = %q, want vcs_url at branch master", lager.PURL)
+	}
+	if cowboy := findDep(result.Direct, "cowboy"); cowboy.PURL != "pkg:hex/cowboy@2.10.0" {
+		t.Errorf("cowboy PURL = %q", cowboy.PURL)
+	}
+}
+
+func TestRebarLock(t *testing.T) {
+	result, err := resolve.Parse("rebar-lock", loadFixture(t, "rebar.lock"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if result.Ecosystem != "hex" {
+		t.Errorf("Ecosystem = %q, want %q", result.Ecosystem, "hex")
+	}
+	if len(result.Direct) != 6 {
+		t.Fatalf("expected 6 deps, got %d", len(result.Direct))
+	}
+
+	tests := []struct {
+		name, version, purl string
+		indirect            bool
+	}{
+		{"acme_auth", "1.3.0", "pkg:hex/acme/acme_auth@1.3.0", false},
+		{"cowboy", "2.10.0", "pkg:hex/cowboy@2.10.0", false},
+		{"cowlib", "2.12.1", "pkg:hex/cowlib@2.12.1", true},
+		{"jsx", "bc56ba4e7bbc0fe6ee9f7ed6ed6d8fd1a9dc6a56", "pkg:hex/jsx@bc56ba4e7bbc0fe6ee9f7ed6ed6d8fd1a9dc6a56?vcs_url=git%2Bhttps:%2F%2Fgithub.com%2Ftalentdeficit%2Fjsx.git%40bc56ba4e7bbc0fe6ee9f7ed6ed6d8fd1a9dc6a56", false},
+	}
+	for _, tt := range tests {
+		dep := findDep(result.Direct, tt.name)
+		if dep == nil {
+			t.Errorf("missing %s", tt.name)
+			continue
+		}
+		if dep.Version != tt.version || dep.PURL != tt.purl || dep.Indirect != tt.indirect {
+			t.Errorf("%s = {%q %q indirect=%v}, want {%q %q indirect=%v}", tt.name, dep.Version, dep.PURL, dep.Indirect, tt.version, tt.purl, tt.indirect)
+		}
+		if dep.Deps != nil {
+			t.Errorf("%s Deps should be nil for a flat list", tt.name)
+		}
+	}
+
+	jsx := findDep(result.Direct, "jsx")
+	if jsx.Source != "git" || jsx.Location != "https://github.com/talentdeficit/jsx.git" {
+		t.Errorf("jsx source = %q %q, want git", jsx.Source, jsx.Location)
+	}
+	lager := findDep(result.Direct, "lager")
+	if lager.Version != "" || lager.Constraint != "master" {
+		t.Errorf("lager = {%q %q}, want branch master as constraint", lager.Version, lager.Constraint)
+	}
+}
+
+func TestMixLock(t *testing.T) {
+	result, err := resolve.Parse("mix-lock", loadFixture(t, "mix.lock"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	checkTreeResult(t, result, "hex", 3, []depCheck{
+		{"acme_auth", "1.3.0", 1},
+		{"plug", "1.15.2", 3},
+		{"ueberauth", "1f2ab3c9a8b7e6d5c4b3a2f1e0d9c8b7a6f5e4d3", 0},
+	})
+
+	acme := findDep(result.Direct, "acme_auth")
+	if acme.PURL != "pkg:hex/acme/acme_auth@1.3.0" {
+		t.Errorf("acme_auth PURL = %q, want organisation namespace", acme.PURL)
+	}
+	jason := findDep(acme.Deps, "jason")
+	if jason == nil || jason.Constraint != "~> 1.2" {
+		t.Fatalf("jason = %+v, want constraint ~> 1.2", jason)
+	}
+	// decimal is an optional requirement of jason, but it's locked
+	if decimal := findDep(jason.Deps, "decimal"); decimal == nil || decimal.Version != "2.1.1" {
+		t.Errorf("decimal = %+v, want 2.1.1 under jason", decimal)
+	}
+
+	ueberauth := findDep(result.Direct, "ueberauth")
+	if ueberauth.Source != "git" || ueberauth.Location != "https://github.com/ueberauth/ueberauth.git" {
+		t.Errorf("ueberauth source = %q %q, want git", ueberauth.Source, ueberauth.Location)
+	}
+}
+
+func TestSbt(t *testing.T) {
+	result, err := resolve.Parse("sbt", loadFixture(t, "sbt-tree.txt"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	checkTreeResult(t, result, "maven", 5, []depCheck{
+		{"com.typesafe.akka:akka-actor_2.13", "2.6.20", 2},
+		{"io.circe:circe-core_2.13", "0.14.6", 2},
+		{"ch.qos.logback:logback-classic", "1.4.14", 2},
+		{"org.scala-js:scalajs-dom_sjs1_2.13", "2.8.0", 0},
+		{"org.slf4j:slf4j-api", "2.0.9", 0},
+	})
+
+	akka := findDep(result.Direct, "com.typesafe.akka:akka-actor_2.13")
+	if akka.PURL != "pkg:maven/com.typesafe.akka/akka-actor_2.13@2.6.20" || akka.Target != "2.13" {
+		t.Errorf("akka-actor = {%q %q}, want maven PURL with target 2.13", akka.PURL, akka.Target)
+	}
+	if config := findDep(akka.Deps, "com.typesafe:config"); config == nil || config.Target != "" {
+		t.Errorf("config = %+v, want a Java artifact with no target", config)
+	}
+	if js := findDep(result.Direct, "org.scala-js:scalajs-dom_sjs1_2.13"); js.Target != "sjs1_2.13" {
+		t.Errorf("scalajs-dom target = %q, want %q", js.Target, "sjs1_2.13")
+	}
+
+	circe := findDep(result.Direct, "io.circe:circe-core_2.13")
+	cats := findDep(circe.Deps, "org.typelevel:cats-core_2.13")
+	if cats == nil || len(cats.Deps) != 1 {
+		t.Fatalf("cats-core = %+v, want 1 dep", cats)
+	}
+
+	logback := findDep(result.Direct, "ch.qos.logback:logback-classic")
+	slf4j := findDep(logback.Deps, "org.slf4j:slf4j-api")
+	if slf4j == nil {
+		t.Fatal("missing evicted slf4j-api under logback-classic")
+	}
+	if slf4j.Version != "2.0.9" || slf4j.Constraint != "2.0.7" {
+		t.Errorf("slf4j-api = {%q %q}, want version 2.0.9 and constraint 2.0.7", slf4j.Version, slf4j.Constraint)
+	}
+	want := []resolve.Diagnostic{{Kind: "evicted", Message: "evicted by: 2.0.9"}}
+	if !slices.Equal(slf4j.Diagnostics, want) {
+		t.Errorf("slf4j-api diagnostics = %v, want %v", slf4j.Diagnostics, want)
+	}
+}
+
+func TestSbtBrowseTreeJSON(t *testing.T) {
+	result, err := resolve.Parse("sbt", loadFixture(t, "sbt-tree.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	checkTreeResult(t, result, "maven", 2, []depCheck{
+		{"com.example:core_3", "0.1.0", 1},
+		{"com.example:api_3", "0.1.0", 2},
+	})
+
+	api := findDep(result.Direct, "com.example:api_3")
+	if api.Source != "workspace" || api.Target != "3" {
+		t.Errorf("api = {%q %q}, want workspace project for Scala 3", api.Source, api.Target)
+	}
+	http4s := findDep(api.Deps, "org.http4s:http4s-core_3")
+	if cats := findDep(http4s.Deps, "org.typelevel:cats-core_3"); cats == nil || cats.Constraint != "2.9.0" || len(cats.Diagnostics) != 1 {
+		t.Errorf("cats-core under http4s = %+v, want evicted 2.9.0", cats)
+	}
+}
+
+func TestSbtDot(t *testing.T) {
+	result, err := resolve.Parse("sbt", loadFixture(t, "sbt-deps.dot"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	checkTreeResult(t, result, "maven", 2, []depCheck{
+		{"ch.qos.logback:logback-classic", "1.4.14", 2},
+		{"org.slf4j:slf4j-api", "2.0.9", 0},
+	})
+
+	logback := findDep(result.Direct, "ch.qos.logback:logback-classic")
+	slf4j := findDep(logback.Deps, "org.slf4j:slf4j-api")
+	if slf4j == nil || slf4j.Version != "2.0.9" || slf4j.Constraint != "2.0.7" || len(slf4j.Diagnostics) != 1 {
+		t.Errorf("slf4j-api under logback = %+v, want evicted 2.0.7", slf4j)
+	}
+}
+
 func TestLein(t *testing.T) {
 	result, err := resolve.Parse("lein", loadFixture(t, "lein.txt"))
 	if err != nil {
@@ -596,6 +2101,48 @@ func TestLein(t *testing.T) {
 	}
 }
 
+func TestLeinConflicts(t *testing.T) {
+	result, err := resolve.Parse("lein", loadFixture(t, "lein-conflicts.txt"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	checkTreeResult(t, result, "clojars", 7, []depCheck{
+		{"cheshire", "5.12.0", 1},
+		{"clj-http", "3.12.3", 2},
+		{"cljs-ajax", "0.8.4", 0},
+		{"org.clojure/tools.reader", "1.3.6", 0},
+		{"ring", "1.10.0", 1},
+	})
+
+	commonsIO := findDep(findDep(result.Direct, "clj-http").Deps, "commons-io")
+	if commonsIO == nil {
+		t.Fatal("missing commons-io under clj-http")
+	}
+	want := []resolve.Diagnostic{
+		{Kind: "conflict", Message: "commons-io 2.11.0 overrides 2.15.1 required via ring 1.10.0 -> ring/ring-core 1.10.0"},
+		{Kind: "conflict", Message: "commons-io 2.11.0 overrides 2.6 required via cheshire 5.12.0"},
+	}
+	if !slices.Equal(commonsIO.Diagnostics, want) {
+		t.Errorf("commons-io diagnostics = %v, want %v", commonsIO.Diagnostics, want)
+	}
+	if reader := findDep(result.Direct, "org.clojure/tools.reader"); len(reader.Diagnostics) != 1 {
+		t.Errorf("tools.reader diagnostics = %v, want 1 conflict", reader.Diagnostics)
+	}
+
+	httpclient := findDep(findDep(result.Direct, "clj-http").Deps, "org.apache.httpcomponents/httpclient")
+	if httpclient == nil || httpclient.PURL != "pkg:clojars/org.apache.httpcomponents%2Fhttpclient@4.5.13" {
+		t.Errorf("httpclient = %+v, want no qualifiers from its exclusions", httpclient)
+	}
+	lwjgl := findDep(result.Direct, "org.lwjgl/lwjgl")
+	if lwjgl.PURL != "pkg:clojars/org.lwjgl%2Flwjgl@3.3.1?classifier=natives-linux" {
+		t.Errorf("lwjgl PURL = %q, want classifier qualifier", lwjgl.PURL)
+	}
+	if midje := findDep(result.Direct, "midje"); midje.Scope != "test" {
+		t.Errorf("midje scope = %q, want %q", midje.Scope, "test")
+	}
+}
+
 func TestConan(t *testing.T) {
 	result, err := resolve.Parse("conan", loadFixture(t, "conan.txt"))
 	if err != nil {
@@ -617,6 +2164,40 @@ func TestConan(t *testing.T) {
 	}
 }
 
+func TestConanGraph(t *testing.T) {
+	result, err := resolve.Parse("conan", loadFixture(t, "conan-graph.json"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	// zlib is only a transitive requirement of the consumer
+	checkTreeResult(t, result, "conan", 4, []depCheck{
+		{"openssl", "3.2.0", 1},
+		{"fmt", "10.2.1", 0},
+		{"cmake", "3.28.1", 1},
+		{"gtest", "1.14.0", 0},
+	})
+
+	openssl := findDep(result.Direct, "openssl")
+	if openssl.PURL != "pkg:conan/openssl@3.2.0?rrev=1e8e5e4d4f8c9a2b3c7d6e5f4a3b2c1d" || openssl.Scope != "" {
+		t.Errorf("openssl = %+v, want host dep with rrev qualifier", openssl)
+	}
+	if fmt := findDep(result.Direct, "fmt"); fmt.PURL != "pkg:conan/fmt@10.2.1?channel=stable&rrev=2b4e8d1c0a9f8e7d6c5b4a3f2e1d0c9b&user=acme" {
+		t.Errorf("fmt PURL = %q, want user and channel qualifiers", fmt.PURL)
+	}
+
+	cmake := findDep(result.Direct, "cmake")
+	if cmake.Scope != "build" {
+		t.Errorf("cmake scope = %q, want build", cmake.Scope)
+	}
+	// The build-context openssl is a separate node from the host one
+	if buildSSL := cmake.Deps[0]; buildSSL.Name != "openssl" || buildSSL.Scope != "build" || buildSSL.Deduped {
+		t.Errorf("cmake dep = %+v, want build-context openssl", buildSSL)
+	}
+	if gtest := findDep(result.Direct, "gtest"); gtest.Scope != "test" {
+		t.Errorf("gtest scope = %q, want test", gtest.Scope)
+	}
+}
+
 func TestHelm(t *testing.T) {
 	result, err := resolve.Parse("helm", loadFixture(t, "helm.txt"))
 	if err != nil {
@@ -637,6 +2218,50 @@ func TestHelm(t *testing.T) {
 	}
 }
 
+func TestHelmUmbrella(t *testing.T) {
+	result, err := resolve.Parse("helm", loadFixture(t, "helm-umbrella.txt"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(result.Direct) != 4 {
+		t.Fatalf("expected 4 deps, got %d", len(result.Direct))
+	}
+
+	postgres := findDep(result.Direct, "postgresql")
+	if postgres.PURL != "pkg:helm/postgresql@12.1.9?repository_url=registry-1.docker.io%2Fbitnamicharts" {
+		t.Errorf("postgresql PURL = %q, want OCI registry qualifier", postgres.PURL)
+	}
+	if len(postgres.Diagnostics) != 0 {
+		t.Errorf("postgresql diagnostics = %+v, want none", postgres.Diagnostics)
+	}
+	if len(postgres.Deps) != 1 || postgres.Deps[0].Name != "common" || postgres.Deps[0].Version != "" || postgres.Deps[0].Constraint != "2.x.x" {
+		t.Errorf("postgresql deps = %+v, want vendored common subchart with constraint 2.x.x", postgres.Deps)
+	}
+
+	// The vendored redis archive is 18.1.0, not the 18.4.0 the chart asks for
+	redis := findDep(result.Direct, "redis")
+	if redis.PURL != "pkg:helm/redis@18.4.0?repository_url=https:%2F%2Fcharts.bitnami.com%2Fbitnami" {
+		t.Errorf("redis PURL = %q", redis.PURL)
+	}
+	if len(redis.Diagnostics) != 2 || redis.Diagnostics[0].Kind != "wrong-version" || redis.Diagnostics[0].Message != "wrong version" {
+		t.Fatalf("redis diagnostics = %+v, want wrong-version and version-mismatch", redis.Diagnostics)
+	}
+	if d := redis.Diagnostics[1]; d.Kind != "version-mismatch" || !strings.Contains(d.Message, "18.1.0") {
+		t.Errorf("redis archive diagnostic = %+v, want version-mismatch naming 18.1.0", d)
+	}
+	if len(redis.Deps) != 1 || redis.Deps[0].Name != "common" {
+		t.Errorf("redis deps = %+v, want the vendored archive's common subchart", redis.Deps)
+	}
+
+	metrics := findDep(result.Direct, "metrics")
+	if metrics.Source != "path" || metrics.Location != "../metrics" || metrics.PURL != "" {
+		t.Errorf("metrics = %+v, want local path chart", metrics)
+	}
+	if nginx := findDep(result.Direct, "nginx"); len(nginx.Diagnostics) != 1 || nginx.Diagnostics[0].Kind != "missing" {
+		t.Errorf("nginx diagnostics = %+v, want missing", nginx.Diagnostics)
+	}
+}
+
 func TestParseEmptyInput(t *testing.T) {
 	_, err := resolve.Parse("npm", []byte(""))
 	if err == nil {
@@ -81,6 +81,23 @@ func TabContentParser(content string) (string, string, bool) {
 // The contentParser receives the content string and returns (name, version, deps-placeholder).
 // Deps is set to non-nil empty slice to indicate tree structure is available.
 func BuildTree(lines []TreeLine, ecosystem string, contentParser func(string) (string, string, bool)) []*Dep {
+	return BuildDepTree(lines, func(content string) (*Dep, bool) {
+		name, version, ok := contentParser(content)
+		if !ok {
+			return nil, false
+		}
+		return &Dep{
+			PURL:    MakePURL(ecosystem, name, version),
+			Name:    name,
+			Version: version,
+		}, true
+	})
+}
+
+// BuildDepTree is like BuildTree but the parser constructs each Dep itself,
+// for output that carries more per line than a name and version.
+// Deps is set to non-nil empty slice to indicate tree structure is available.
+func BuildDepTree(lines []TreeLine, parse func(string) (*Dep, bool)) []*Dep {
 	if len(lines) == 0 {
 		return nil
 	}
@@ -94,16 +111,12 @@ func BuildTree(lines []TreeLine, ecosystem string, contentParser func(string) (s
 	var stack []stackEntry
 
 	for _, line := range lines {
-		name, version, ok := contentParser(line.Content)
+		dep, ok := parse(line.Content)
 		if !ok {
 			continue
 		}
-
-		dep := &Dep{
-			PURL:    MakePURL(ecosystem, name, version),
-			Name:    name,
-			Version: version,
-			Deps:    []*Dep{}, // non-nil to indicate tree structure
+		if dep.Deps == nil {
+			dep.Deps = []*Dep{} // non-nil to indicate tree structure
 		}
 
 		// Pop stack entries that are at the same depth or deeper
@@ -106,6 +106,39 @@ func TestBuildTree(t *testing.T) {
 	}
 }
 
+func TestBuildDepTree(t *testing.T) {
+	lines := []TreeLine{
+		{Depth: 0, Content: "a 1.0.0 dev"},
+		{Depth: 1, Content: "b 2.0.0"},
+		{Depth: 0, Content: "not a dep"},
+		{Depth: 0, Content: "c 3.0.0"},
+	}
+	deps := BuildDepTree(lines, func(content string) (*Dep, bool) {
+		parts := splitFields(content)
+		if len(parts) < 2 || parts[0] == "not" {
+			return nil, false
+		}
+		dep := &Dep{Name: parts[0], Version: parts[1]}
+		if len(parts) > 2 {
+			dep.Scope = parts[2]
+		}
+		return dep, true
+	})
+
+	if len(deps) != 2 {
+		t.Fatalf("expected 2 root deps, got %d", len(deps))
+	}
+	if deps[0].Scope != "dev" {
+		t.Errorf("first dep scope = %q, want %q", deps[0].Scope, "dev")
+	}
+	if len(deps[0].Deps) != 1 || deps[0].Deps[0].Name != "b" {
+		t.Errorf("first dep children = %v, want [b]", deps[0].Deps)
+	}
+	if deps[1].Deps == nil {
+		t.Error("Deps should be non-nil empty slice for tree managers")
+	}
+}
+
 func splitFields(s string) []string {
 	var fields []string
 	field := ""